@@ -0,0 +1,288 @@
+// Package budget checks GitHub Actions usage against limits, in minutes or estimated dollars, for all of the
+// usage, an owner or a repository.
+package budget
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultWarn is the fraction of a budget at which usage is reported as near the limit
+const DefaultWarn = 0.8
+
+// Unit is what a limit is measured in
+type Unit string
+
+// The units that limits can be set in
+const (
+	Minutes Unit = "minutes"
+	Dollars Unit = "dollars"
+)
+
+// Limit is the most usage a budget allows, e.g. 3000 minutes or $25
+type Limit struct {
+	Amount float64
+	Unit   Unit
+}
+
+// InvalidLimitError is an error when a limit isn't a number of minutes or a dollar amount
+type InvalidLimitError string
+
+// Error returns a formatted error message for InvalidLimitError
+func (e InvalidLimitError) Error() string {
+	return fmt.Sprintf("Invalid budget: %s (must be minutes, e.g. 3000, or dollars, e.g. $25)", string(e))
+}
+
+// ParseLimit reads a limit in minutes (e.g. 3000) or dollars (e.g. $25)
+func ParseLimit(value string) (Limit, error) {
+	limit := Limit{Unit: Minutes}
+	amount := strings.TrimSpace(value)
+	if strings.HasPrefix(amount, "$") {
+		limit.Unit = Dollars
+		amount = amount[1:]
+	}
+	parsed, err := strconv.ParseFloat(amount, 64)
+	if err != nil || parsed <= 0 {
+		return Limit{}, InvalidLimitError(value)
+	}
+	limit.Amount = parsed
+	return limit, nil
+}
+
+// Format formats an amount in the limit's unit, e.g. "1200 minutes" or "$12.50"
+func (l Limit) Format(amount float64) string {
+	if l.Unit == Dollars {
+		return fmt.Sprintf("$%.2f", amount)
+	}
+	return fmt.Sprintf("%.0f minutes", amount)
+}
+
+// UnmarshalYAML reads a limit from a YAML scalar, e.g. 3000 or $25
+func (l *Limit) UnmarshalYAML(node *yaml.Node) error {
+	limit, err := ParseLimit(node.Value)
+	if err != nil {
+		return err
+	}
+	*l = limit
+	return nil
+}
+
+// Budgets are the limits for all of the usage, for owners by login and for repositories by full name
+type Budgets struct {
+	Total        *Limit           `yaml:"total"`
+	Owners       map[string]Limit `yaml:"owners"`
+	Repositories map[string]Limit `yaml:"repositories"`
+	// Warn is the fraction of a limit at which usage is reported as near the limit
+	Warn float64 `yaml:"warn"`
+}
+
+// New returns budgets without any limits
+func New() *Budgets {
+	return &Budgets{Owners: map[string]Limit{}, Repositories: map[string]Limit{}, Warn: DefaultWarn}
+}
+
+// Load reads the budgets in the YAML file at path
+func Load(path string) (*Budgets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read budgets: %w", err)
+	}
+	budgets := New()
+	if err := yaml.Unmarshal(data, budgets); err != nil {
+		return nil, fmt.Errorf("could not parse budgets in %s: %w", path, err)
+	}
+	if budgets.Warn <= 0 || budgets.Warn > 1 {
+		return nil, InvalidWarnError(budgets.Warn)
+	}
+	return budgets, nil
+}
+
+// InvalidWarnError is an error when the fraction of a budget at which to warn isn't between 0 and 1
+type InvalidWarnError float64
+
+// Error returns a formatted error message for InvalidWarnError
+func (e InvalidWarnError) Error() string {
+	return fmt.Sprintf("Invalid budget warning: %g (must be more than 0 and at most 1)", float64(e))
+}
+
+// Set adds a budget from an option: a limit for all of the usage (e.g. 3000 or $25), or a limit for an owner or
+// repository (e.g. codiform=$25 or codiform/api=500), replacing any limit it already had
+func (b *Budgets) Set(value string) error {
+	name, amount, scoped := strings.Cut(value, "=")
+	if !scoped {
+		amount = name
+	}
+	limit, err := ParseLimit(amount)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !scoped:
+		b.Total = &limit
+	case strings.Contains(name, "/"):
+		if b.Repositories == nil {
+			b.Repositories = map[string]Limit{}
+		}
+		b.Repositories[name] = limit
+	default:
+		if b.Owners == nil {
+			b.Owners = map[string]Limit{}
+		}
+		b.Owners[name] = limit
+	}
+	return nil
+}
+
+// Empty returns true if there aren't any limits
+func (b *Budgets) Empty() bool {
+	return b == nil || (b.Total == nil && len(b.Owners) == 0 && len(b.Repositories) == 0)
+}
+
+// Usage is the usage that a limit is checked against
+type Usage struct {
+	// Minutes are the billable minutes, as GitHub counts them against included minutes (see cost.Model.Minutes)
+	Minutes float64
+	Cost    float64
+}
+
+// in returns the usage in a unit
+func (u Usage) in(unit Unit) float64 {
+	if unit == Dollars {
+		return u.Cost
+	}
+	return u.Minutes
+}
+
+// Totals are all of the usage, and the usage of each owner and repository
+type Totals struct {
+	Total        Usage
+	Owners       map[string]Usage
+	Repositories map[string]Usage
+}
+
+// Scope is what a budget limits
+type Scope string
+
+// The scopes that budgets can have
+const (
+	ScopeTotal      Scope = "total"
+	ScopeOwner      Scope = "owner"
+	ScopeRepository Scope = "repository"
+)
+
+// State is how the usage compares to a budget's limit
+type State string
+
+// The states that a budget can be in
+const (
+	StateOK       State = "ok"
+	StateNear     State = "near"
+	StateExceeded State = "exceeded"
+)
+
+// Status is the usage of a budget
+type Status struct {
+	Scope Scope
+	// Name is the login of the owner or the full name of the repository, or empty for the total
+	Name  string
+	Limit Limit
+	// Used is the usage in the limit's unit
+	Used  float64
+	State State
+	// Unmatched is set when the owner or repository isn't in the usage (e.g. because its name is misspelled), so
+	// the budget couldn't really be checked
+	Unmatched bool
+}
+
+// Percent is the usage as a percentage of the limit
+func (s Status) Percent() float64 {
+	return s.Used * percent / s.Limit.Amount
+}
+
+const percent = 100
+
+// Check compares the usage with each of the budgets: the total first, then the owners and repositories by name.
+// Names are matched ignoring case, as GitHub does; budgets for names that aren't in the usage are Unmatched.
+func (b *Budgets) Check(totals Totals) []Status {
+	if b.Empty() {
+		return nil
+	}
+	var statuses []Status
+	if b.Total != nil {
+		statuses = append(statuses, b.status(ScopeTotal, "", *b.Total, totals.Total))
+	}
+	owners := byLowerName(totals.Owners)
+	for _, name := range sortedNames(b.Owners) {
+		usage, found := owners[strings.ToLower(name)]
+		status := b.status(ScopeOwner, name, b.Owners[name], usage)
+		status.Unmatched = !found
+		statuses = append(statuses, status)
+	}
+	repos := byLowerName(totals.Repositories)
+	for _, name := range sortedNames(b.Repositories) {
+		usage, found := repos[strings.ToLower(name)]
+		status := b.status(ScopeRepository, name, b.Repositories[name], usage)
+		status.Unmatched = !found
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Unmatched returns the statuses of budgets for owners or repositories that aren't in the usage
+func Unmatched(statuses []Status) []Status {
+	var unmatched []Status
+	for _, status := range statuses {
+		if status.Unmatched {
+			unmatched = append(unmatched, status)
+		}
+	}
+	return unmatched
+}
+
+// byLowerName returns the usage by lower-case name, adding up names that only differ by case
+func byLowerName(usage map[string]Usage) map[string]Usage {
+	lower := make(map[string]Usage, len(usage))
+	for name, u := range usage {
+		key := strings.ToLower(name)
+		sum := lower[key]
+		sum.Minutes += u.Minutes
+		sum.Cost += u.Cost
+		lower[key] = sum
+	}
+	return lower
+}
+
+func (b *Budgets) status(scope Scope, name string, limit Limit, usage Usage) Status {
+	status := Status{Scope: scope, Name: name, Limit: limit, Used: usage.in(limit.Unit), State: StateOK}
+	switch {
+	case status.Used > limit.Amount:
+		status.State = StateExceeded
+	case status.Used >= limit.Amount*b.Warn:
+		status.State = StateNear
+	}
+	return status
+}
+
+// Exceeded returns true if any of the statuses are over their limit
+func Exceeded(statuses []Status) bool {
+	for _, status := range statuses {
+		if status.State == StateExceeded {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(limits map[string]Limit) []string {
+	names := make([]string, 0, len(limits))
+	for name := range limits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,109 @@
+package budget
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("3000")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Amount: 3000, Unit: Minutes}, limit)
+
+	limit, err = ParseLimit("$25.50")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Amount: 25.5, Unit: Dollars}, limit)
+
+	_, err = ParseLimit("lots")
+	assert.Equal(t, InvalidLimitError("lots"), err)
+
+	_, err = ParseLimit("$0")
+	assert.Equal(t, InvalidLimitError("$0"), err)
+}
+
+func TestBudgets_Set(t *testing.T) {
+	// Given
+	budgets := New()
+
+	// When
+	require.NoError(t, budgets.Set("3000"))
+	require.NoError(t, budgets.Set("codiform=$25"))
+	require.NoError(t, budgets.Set("codiform/api=500"))
+
+	// Then
+	assert.Equal(t, &Limit{Amount: 3000, Unit: Minutes}, budgets.Total)
+	assert.Equal(t, map[string]Limit{"codiform": {Amount: 25, Unit: Dollars}}, budgets.Owners)
+	assert.Equal(t, map[string]Limit{"codiform/api": {Amount: 500, Unit: Minutes}}, budgets.Repositories)
+}
+
+func TestLoad(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "budgets.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+warn: 0.9
+total: 10000
+owners:
+  codiform: $200
+repositories:
+  codiform/api: 1000
+`), 0o600))
+
+	// When
+	budgets, err := Load(path)
+
+	// Then
+	require.NoError(t, err)
+	assert.InDelta(t, 0.9, budgets.Warn, 0.0001)
+	assert.Equal(t, &Limit{Amount: 10000, Unit: Minutes}, budgets.Total)
+	assert.Equal(t, Limit{Amount: 200, Unit: Dollars}, budgets.Owners["codiform"])
+	assert.Equal(t, Limit{Amount: 1000, Unit: Minutes}, budgets.Repositories["codiform/api"])
+}
+
+func TestLoad_InvalidWarn(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "budgets.yml")
+	require.NoError(t, os.WriteFile(path, []byte("warn: 80\n"), 0o600))
+
+	// When
+	_, err := Load(path)
+
+	// Then
+	assert.Equal(t, InvalidWarnError(80), err)
+}
+
+func TestBudgets_Check(t *testing.T) {
+	// Given
+	budgets := New()
+	require.NoError(t, budgets.Set("100"))
+	require.NoError(t, budgets.Set("Codiform=$1"))
+	require.NoError(t, budgets.Set("codiform/api=10"))
+	require.NoError(t, budgets.Set("codiform/web=10"))
+	totals := Totals{
+		Total:        Usage{Minutes: 30, Cost: 2},
+		Owners:       map[string]Usage{"codiform": {Minutes: 30, Cost: 2}},
+		Repositories: map[string]Usage{"codiform/api": {Minutes: 9}},
+	}
+
+	// When
+	statuses := budgets.Check(totals)
+
+	// Then
+	assert.Equal(t, []Status{
+		{Scope: ScopeTotal, Limit: Limit{Amount: 100, Unit: Minutes}, Used: 30, State: StateOK},
+		{Scope: ScopeOwner, Name: "Codiform", Limit: Limit{Amount: 1, Unit: Dollars}, Used: 2, State: StateExceeded},
+		{Scope: ScopeRepository, Name: "codiform/api", Limit: Limit{Amount: 10, Unit: Minutes}, Used: 9, State: StateNear},
+		{Scope: ScopeRepository, Name: "codiform/web", Limit: Limit{Amount: 10, Unit: Minutes}, Used: 0, State: StateOK, Unmatched: true},
+	}, statuses)
+	assert.True(t, Exceeded(statuses))
+	assert.Equal(t, statuses[3:], Unmatched(statuses))
+	assert.InDelta(t, 200.0, statuses[1].Percent(), 0.0001)
+}
+
+func TestBudgets_Check_Empty(t *testing.T) {
+	assert.Nil(t, New().Check(Totals{}))
+	assert.True(t, New().Empty())
+}
@@ -0,0 +1,249 @@
+package client
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cli/go-gh/pkg/config"
+)
+
+// Cache is an http.RoundTripper that keeps successful GET responses on disk, keyed by URL and by the
+// credentials they were requested with, so that one account is never served another's responses. Responses
+// younger than the TTL are served without sending a request; older ones are revalidated with If-None-Match
+// or If-Modified-Since, and when GitHub answers 304 Not Modified (which doesn't count against the rate limit)
+// the cached response is served instead. Responses that haven't been stored for CacheRetention are removed,
+// as are the oldest ones beyond MaxCacheEntries.
+type Cache struct {
+	dir  string
+	ttl  time.Duration
+	next http.RoundTripper
+	now  func() time.Time
+
+	mu     sync.Mutex
+	stats  CacheStats
+	pruned sync.Once
+}
+
+const (
+	// CacheRetention is how long a cached response is kept after it was last downloaded or revalidated
+	CacheRetention = 30 * 24 * time.Hour
+	// MaxCacheEntries is the most responses kept in the cache; the least recently stored are removed first
+	MaxCacheEntries = 10000
+)
+
+// CacheStats counts how the requests made through a Cache were answered
+type CacheStats struct {
+	// Fresh is the number of responses served from the cache without a request
+	Fresh int
+	// Revalidated is the number of responses served from the cache after a 304 Not Modified
+	Revalidated int
+	// Fetched is the number of responses that had to be downloaded
+	Fetched int
+}
+
+// cacheEntry is a response stored on disk
+type cacheEntry struct {
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	Stored     time.Time   `json:"stored"`
+}
+
+// DefaultCacheDir is where responses are cached unless another directory is specified: the user's cache
+// directory, or the gh state directory if there isn't one
+func DefaultCacheDir() string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "gh-actions-usage")
+	}
+	return filepath.Join(config.StateDir(), "actions-usage", "cache")
+}
+
+// NewCache returns a Cache in dir that serves responses younger than ttl without revalidating them, and
+// sends other requests using next
+func NewCache(dir string, ttl time.Duration, next http.RoundTripper) *Cache {
+	return &Cache{dir: dir, ttl: ttl, next: next, now: time.Now}
+}
+
+// Stats returns the number of responses served from the cache or downloaded so far
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// RoundTrip serves the request from the cache if it can, revalidating or downloading it otherwise
+func (c *Cache) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Method != http.MethodGet {
+		return c.next.RoundTrip(request) //nolint:wrapcheck // the transport's errors are returned unchanged
+	}
+	key := cacheKey(request)
+	entry := c.load(key, request.URL.String())
+	if entry != nil && c.now().Sub(entry.Stored) < c.ttl {
+		c.count(func(stats *CacheStats) { stats.Fresh++ })
+		return entry.response(request), nil
+	}
+
+	conditional := request
+	if entry != nil {
+		conditional = request.Clone(request.Context())
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			conditional.Header.Set("If-None-Match", etag)
+		}
+		if modified := entry.Header.Get("Last-Modified"); modified != "" {
+			conditional.Header.Set("If-Modified-Since", modified)
+		}
+	}
+	response, err := c.next.RoundTrip(conditional)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the transport's errors are returned unchanged
+	}
+
+	if response.StatusCode == http.StatusNotModified && entry != nil {
+		_ = response.Body.Close()
+		for name, values := range response.Header {
+			entry.Header[name] = values
+		}
+		entry.Stored = c.now()
+		c.save(key, entry)
+		c.count(func(stats *CacheStats) { stats.Revalidated++ })
+		return entry.response(request), nil
+	}
+
+	c.count(func(stats *CacheStats) { stats.Fetched++ })
+	if response.StatusCode != http.StatusOK {
+		return response, nil
+	}
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %w", err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	c.save(key, &cacheEntry{URL: request.URL.String(), StatusCode: response.StatusCode, Header: response.Header.Clone(), Body: body, Stored: c.now()})
+	return response, nil
+}
+
+func (c *Cache) count(update func(stats *CacheStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	update(&c.stats)
+}
+
+// cacheKey identifies the response to a request: a hash of its host, URL and Authorization header, so that
+// responses for different accounts or tokens (which may see different repositories) are kept apart without
+// storing the credentials themselves
+func cacheKey(request *http.Request) string {
+	sum := sha256.Sum256([]byte(request.URL.Host + "\x00" + request.URL.String() + "\x00" + request.Header.Get("Authorization")))
+	return hex.EncodeToString(sum[:])
+}
+
+// path is the file that the response for a key is stored in
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// load returns the cached response for a key, or nil if there isn't one that can be read for the URL
+func (c *Cache) load(key, url string) *cacheEntry {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return nil
+	}
+	if entry.Header == nil {
+		entry.Header = make(http.Header)
+	}
+	return &entry
+}
+
+// save stores a response; the cache is only an optimization, so a response that can't be stored is skipped
+func (c *Cache) save(key string, entry *cacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return
+	}
+	c.pruned.Do(c.prune)
+	// write to a temporary file first, so that concurrent reads never see part of a response
+	file, err := os.CreateTemp(c.dir, "response-*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+	}
+}
+
+// prune removes responses that haven't been stored within CacheRetention, then the least recently stored
+// ones beyond MaxCacheEntries; like save, it skips anything it can't remove
+func (c *Cache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	type storedFile struct {
+		path     string
+		modified time.Time
+	}
+	files := make([]storedFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(c.dir, entry.Name())
+		if c.now().Sub(info.ModTime()) > CacheRetention {
+			_ = os.Remove(path)
+			continue
+		}
+		files = append(files, storedFile{path: path, modified: info.ModTime()})
+	}
+	if len(files) <= MaxCacheEntries {
+		return
+	}
+	slices.SortFunc(files, func(a, b storedFile) int { return b.modified.Compare(a.modified) })
+	for _, file := range files[MaxCacheEntries:] {
+		_ = os.Remove(file.path)
+	}
+}
+
+// response rebuilds the cached response for a request
+func (e *cacheEntry) response(request *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       request,
+	}
+}
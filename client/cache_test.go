@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestCache(t *testing.T, ttl time.Duration, responses ...*http.Response) (*Cache, *scriptedTransport, *time.Time) {
+	t.Helper()
+	transport := &scriptedTransport{responses: responses}
+	cache := NewCache(t.TempDir(), ttl, transport)
+	now := testNow
+	cache.now = func() time.Time { return now }
+	return cache, transport, &now
+}
+
+func sendCachedRequest(t *testing.T, cache *Cache, method string) (*http.Response, string) {
+	t.Helper()
+	return sendAuthorizedRequest(t, cache, method, "token one")
+}
+
+func sendAuthorizedRequest(t *testing.T, cache *Cache, method, authorization string) (*http.Response, string) {
+	t.Helper()
+	request, err := http.NewRequestWithContext(context.Background(), method, "https://api.github.com/repos/codiform/api/actions/workflows", nil)
+	require.NoError(t, err)
+	request.Header.Set("Authorization", authorization)
+	response, err := cache.RoundTrip(request)
+	require.NoError(t, err)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	return response, string(body)
+}
+
+func TestCache_Fresh(t *testing.T) {
+	// Given
+	cache, transport, now := getTestCache(t, 10*time.Minute, testResponse(http.StatusOK, 4999, `{"total_count": 1}`, "ETag", `"abc"`))
+	_, _ = sendCachedRequest(t, cache, http.MethodGet)
+	*now = now.Add(5 * time.Minute)
+
+	// When
+	response, body := sendCachedRequest(t, cache, http.MethodGet)
+
+	// Then
+	assert.Equal(t, 1, transport.requests)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.JSONEq(t, `{"total_count": 1}`, body)
+	assert.Equal(t, CacheStats{Fresh: 1, Fetched: 1}, cache.Stats())
+}
+
+func TestCache_Revalidated(t *testing.T) {
+	// Given
+	cache, transport, now := getTestCache(t, 0,
+		testResponse(http.StatusOK, 4999, `{"total_count": 1}`, "ETag", `"abc"`, "Last-Modified", "Sun, 18 Oct 2026 11:00:00 GMT"),
+		testResponse(http.StatusNotModified, 4999, "", "ETag", `"abc"`),
+	)
+	_, _ = sendCachedRequest(t, cache, http.MethodGet)
+	*now = now.Add(time.Minute)
+
+	// When
+	response, body := sendCachedRequest(t, cache, http.MethodGet)
+
+	// Then
+	require.Equal(t, 2, transport.requests)
+	assert.Equal(t, `"abc"`, transport.sent[1].Header.Get("If-None-Match"))
+	assert.Equal(t, "Sun, 18 Oct 2026 11:00:00 GMT", transport.sent[1].Header.Get("If-Modified-Since"))
+	assert.Empty(t, transport.sent[0].Header.Get("If-None-Match"))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.JSONEq(t, `{"total_count": 1}`, body)
+	assert.Equal(t, CacheStats{Revalidated: 1, Fetched: 1}, cache.Stats())
+}
+
+func TestCache_Changed(t *testing.T) {
+	// Given
+	cache, _, _ := getTestCache(t, 0,
+		testResponse(http.StatusOK, 4999, `{"total_count": 1}`, "ETag", `"abc"`),
+		testResponse(http.StatusOK, 4998, `{"total_count": 2}`, "ETag", `"def"`),
+		testResponse(http.StatusNotModified, 4998, ""),
+	)
+	_, _ = sendCachedRequest(t, cache, http.MethodGet)
+
+	// When
+	_, changed := sendCachedRequest(t, cache, http.MethodGet)
+	_, revalidated := sendCachedRequest(t, cache, http.MethodGet)
+
+	// Then
+	assert.JSONEq(t, `{"total_count": 2}`, changed)
+	assert.JSONEq(t, `{"total_count": 2}`, revalidated)
+	assert.Equal(t, CacheStats{Revalidated: 1, Fetched: 2}, cache.Stats())
+}
+
+func TestCache_ErrorsNotCached(t *testing.T) {
+	// Given
+	cache, transport, _ := getTestCache(t, time.Hour,
+		testResponse(http.StatusBadGateway, 4999, `{"message": "Bad Gateway"}`),
+		testResponse(http.StatusOK, 4998, `{"total_count": 1}`),
+	)
+	_, _ = sendCachedRequest(t, cache, http.MethodGet)
+
+	// When
+	response, _ := sendCachedRequest(t, cache, http.MethodGet)
+
+	// Then
+	assert.Equal(t, 2, transport.requests)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestCache_OnlyGet(t *testing.T) {
+	// Given
+	cache, transport, _ := getTestCache(t, time.Hour,
+		testResponse(http.StatusOK, 4999, `{}`),
+		testResponse(http.StatusOK, 4998, `{}`),
+	)
+
+	// When
+	_, _ = sendCachedRequest(t, cache, http.MethodPost)
+	_, _ = sendCachedRequest(t, cache, http.MethodPost)
+
+	// Then
+	assert.Equal(t, 2, transport.requests)
+	assert.Equal(t, CacheStats{}, cache.Stats())
+}
+
+func TestCache_SeparateCredentials(t *testing.T) {
+	// Given
+	cache, transport, _ := getTestCache(t, time.Hour,
+		testResponse(http.StatusOK, 4999, `{"total_count": 1}`),
+		testResponse(http.StatusOK, 4998, `{"total_count": 2}`),
+	)
+	_, _ = sendAuthorizedRequest(t, cache, http.MethodGet, "token one")
+
+	// When
+	_, other := sendAuthorizedRequest(t, cache, http.MethodGet, "token two")
+	_, same := sendAuthorizedRequest(t, cache, http.MethodGet, "token one")
+
+	// Then
+	assert.Equal(t, 2, transport.requests)
+	assert.JSONEq(t, `{"total_count": 2}`, other)
+	assert.JSONEq(t, `{"total_count": 1}`, same)
+	assert.Equal(t, CacheStats{Fresh: 1, Fetched: 2}, cache.Stats())
+}
+
+func TestCache_Prune(t *testing.T) {
+	// Given
+	cache, _, _ := getTestCache(t, time.Hour, testResponse(http.StatusOK, 4999, `{}`))
+	expired := filepath.Join(cache.dir, "expired.json")
+	recent := filepath.Join(cache.dir, "recent.json")
+	for path, age := range map[string]time.Duration{expired: CacheRetention + time.Hour, recent: time.Hour} {
+		require.NoError(t, os.WriteFile(path, []byte(`{}`), 0o600))
+		require.NoError(t, os.Chtimes(path, testNow.Add(-age), testNow.Add(-age)))
+	}
+
+	// When
+	_, _ = sendCachedRequest(t, cache, http.MethodGet)
+
+	// Then
+	assert.NoFileExists(t, expired)
+	assert.FileExists(t, recent)
+}
+
+func TestCacheEntry_Response(t *testing.T) {
+	entry := cacheEntry{StatusCode: http.StatusOK, Header: http.Header{"Link": {`<next>; rel="next"`}}, Body: []byte("[]")}
+
+	response := entry.response(nil)
+
+	assert.Equal(t, "200 OK", response.Status)
+	assert.Equal(t, `<next>; rel="next"`, response.Header.Get("Link"))
+	body, _ := io.ReadAll(response.Body)
+	assert.Equal(t, "[]", string(body))
+}
@@ -2,27 +2,105 @@
 package client
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/cli/go-gh"
 	"github.com/cli/go-gh/pkg/api"
+	"github.com/cli/go-gh/pkg/auth"
 )
 
-// New creates a new Client instance, initialized with a GH RESTClient
-func New() Client {
-	rest, err := gh.RESTClient(nil)
+// GitHubHost is the host for github.com, as opposed to a GitHub Enterprise Server instance
+const GitHubHost = "github.com"
+
+// DefaultHost returns the host that gh uses: the host in GH_HOST if it's set, otherwise the host the user is logged
+// in to, or github.com
+func DefaultHost() string {
+	host, _ := auth.DefaultHost()
+	return host
+}
+
+// Options configure the Client created by New
+type Options struct {
+	// CacheDir is where responses are cached between runs, or empty to not cache them
+	CacheDir string
+	// CacheTTL is how long cached responses are used without revalidating them
+	CacheTTL time.Duration
+	// RecordDir is where every response is saved so that the run can be replayed, or empty to not record them
+	RecordDir string
+	// ReplayDir is a recording to serve responses from instead of sending requests, or empty to use the API
+	ReplayDir string
+}
+
+// CurrentHost returns the host of the repository in the current working directory, or an empty string if there
+// is none
+func CurrentHost() string {
+	repo, err := gh.CurrentRepository()
 	if err != nil {
-		panic(err)
+		return ""
 	}
+	return repo.Host()
+}
 
-	return Client{Rest: rest}
+// New creates a new Client instance for the specified host, initialized with a GH RESTClient that respects
+// GitHub's rate limits and, if the options have a cache directory, caches responses. If host is empty, the
+// host configured for gh (or GH_HOST) is used. If the options have a replay directory, the Client serves the
+// recorded responses instead, for the host they were recorded from.
+func New(host string, options Options) (Client, error) {
+	if options.ReplayDir != "" {
+		replayer, err := NewReplayer(options.ReplayDir)
+		if err != nil {
+			return Client{}, err
+		}
+		return Client{Rest: replayer, Host: replayer.Host, RecordedAt: replayer.Recorded}, nil
+	}
+	if host == "" {
+		host = DefaultHost()
+	}
+	limiter := NewRateLimiter(http.DefaultTransport)
+	var transport http.RoundTripper = limiter
+	var cache *Cache
+	if options.CacheDir != "" {
+		cache = NewCache(options.CacheDir, options.CacheTTL, limiter)
+		transport = cache
+	}
+	rest, err := gh.RESTClient(&api.ClientOptions{Host: host, Transport: transport})
+	if err != nil {
+		return Client{}, fmt.Errorf("could not create client for %s: %w", host, err)
+	}
+	if options.RecordDir != "" {
+		if rest, err = NewRecorder(rest, options.RecordDir, host, time.Now()); err != nil {
+			return Client{}, err
+		}
+	}
+
+	return Client{Rest: rest, RateLimit: limiter, Cache: cache, Host: host}, nil
 }
 
 // Client is a GH API client customized for the specifics of `gh-actions-usage`.
 type Client struct {
 	Rest api.RESTClient
+	// RateLimit tracks the API quota used by Rest, if it was created by New
+	RateLimit *RateLimiter
+	// Cache keeps the responses to Rest between runs, if it was created by New with a cache directory
+	Cache *Cache
+	// Host is the GitHub host that Rest sends requests to; empty is the same as github.com
+	Host string
+	// RecordedAt is when the responses were recorded, if Rest replays a recording
+	RecordedAt time.Time
+}
+
+// IsHost returns true if host is the one the client sends requests to
+func (c *Client) IsHost(host string) bool {
+	current := c.Host
+	if current == "" {
+		current = GitHubHost
+	}
+	return strings.EqualFold(current, host)
 }
 
 // Workflow represents a GitHub Actions workflow
@@ -33,12 +111,61 @@ type Workflow struct {
 	ID    uint
 }
 
-// WorkflowUsage is a map of usage by Workflow
-type WorkflowUsage map[Workflow]uint
+// WorkflowUsage is a map of usage by Workflow, retaining the breakdown by runner environment
+type WorkflowUsage map[Workflow]*Usage
 
 // RepoUsage is a map of WorkflowUsage by Repo
 type RepoUsage map[*Repository]WorkflowUsage
 
+// UsageError records a repository or workflow whose usage could not be collected, so that a report can
+// include everything else and list what is missing
+type UsageError struct {
+	// Owner is set instead of Repository when the failure is for an owner, such as its billing summary
+	Owner      *User
+	Repository *Repository
+	// Workflow is nil when the workflows for the repository couldn't be listed
+	Workflow *Workflow
+	Err      error
+}
+
+// Error returns a formatted error message for UsageError
+func (e UsageError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Subject(), e.Reason())
+}
+
+// Unwrap returns the underlying error
+func (e UsageError) Unwrap() error {
+	return e.Err
+}
+
+// Subject describes what couldn't be collected, e.g. "codiform/gh-actions-usage" or
+// "codiform/gh-actions-usage .github/workflows/ci.yml"
+func (e UsageError) Subject() string {
+	var subject string
+	if e.Owner != nil {
+		subject = e.Owner.Login
+	}
+	if e.Repository != nil {
+		subject = e.Repository.FullName
+	}
+	if e.Workflow != nil {
+		subject += " " + e.Workflow.Path
+	}
+	return subject
+}
+
+// Reason returns a brief explanation of the failure, using the status and message for API errors
+func (e UsageError) Reason() string {
+	var httpError api.HTTPError
+	if errors.As(e.Err, &httpError) {
+		return fmt.Sprintf("HTTP %d: %s", httpError.StatusCode, httpError.Message)
+	}
+	if e.Err == nil {
+		return "unknown error"
+	}
+	return e.Err.Error()
+}
+
 // UnexpectedUserTypeError is an error when the user type is unexpected
 type UnexpectedUserTypeError string
 
@@ -56,20 +183,14 @@ func (e UnexpectedHostError) Error() string {
 }
 
 // GetWorkflows returns a slice of Workflow instances, one for each workflow in the repository
-func (c *Client) GetWorkflows(repository Repository) ([]Workflow, error) {
-	var page uint8 = 1
+func (c *Client) GetWorkflows(ctx context.Context, repository Repository) ([]Workflow, error) {
 	var workflows = make([]Workflow, 0)
-
-	for {
-		wfp, err := c.getWorkflowPage(repository, page)
-		if err != nil {
-			return nil, err
-		}
-		if len(wfp) == 0 {
-			break
-		}
-		workflows = append(workflows, wfp...)
-		page++
+	path := fmt.Sprintf("repos/%s/actions/workflows", repository.FullName)
+	err := Paginate(ctx, c, path, func(page workflowPage) {
+		workflows = append(workflows, page.Workflows...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get workflows: %w", err)
 	}
 	return workflows, nil
 }
@@ -79,31 +200,32 @@ type workflowPage struct {
 	TotalCount uint64 `json:"total_count"`
 }
 
-func (c *Client) getWorkflowPage(repository Repository, page uint8) ([]Workflow, error) {
-	response := workflowPage{}
-	url := fmt.Sprintf("repos/%s/actions/workflows?page=%d", repository.FullName, page)
-	err := c.Rest.Get(url, &response)
-	if err != nil {
-		return nil, fmt.Errorf("could not get workflow page: %w", err)
-	}
-	return response.Workflows, nil
-}
-
 // Usage represents the usage of a workflow within the billing period
 type Usage struct {
 	Billable map[string]*UsageDetails `json:"billable"`
+	// Runs is the usage of each run in the billing period, if it was collected
+	Runs []*RunUsage `json:"-"`
 }
 
-// UsageDetails is a sub-item of Usage which is basically just a container for the total milliseconds of usage
+// UsageDetails is a sub-item of Usage containing the total milliseconds of usage in one runner environment,
+// and for workflow runs, the duration of each job
 type UsageDetails struct {
-	TotalMs uint `json:"total_ms"`
+	TotalMs uint     `json:"total_ms"`
+	Jobs    uint     `json:"jobs,omitempty"`
+	JobRuns []JobRun `json:"job_runs,omitempty"`
+}
+
+// JobRun is the billable duration of a single job within a workflow run
+type JobRun struct {
+	JobID      uint `json:"job_id"`
+	DurationMs uint `json:"duration_ms"`
 }
 
 // GetWorkflowUsage returns the Usage for a Workflow in a Repository
-func (c *Client) GetWorkflowUsage(repository Repository, workflow Workflow) (*Usage, error) {
+func (c *Client) GetWorkflowUsage(ctx context.Context, repository Repository, workflow Workflow) (*Usage, error) {
 	response := Usage{}
 	path := fmt.Sprintf("repos/%s/actions/workflows/%d/timing", repository.FullName, workflow.ID)
-	err := c.Rest.Get(path, &response)
+	err := c.Rest.DoWithContext(ctx, http.MethodGet, path, nil, &response)
 	if err != nil {
 		return nil, fmt.Errorf("could not get workflow usage: %w", err)
 	}
@@ -113,12 +235,24 @@ func (c *Client) GetWorkflowUsage(repository Repository, workflow Workflow) (*Us
 // TotalMs sums the milliseconds across all runner environments
 func (u *Usage) TotalMs() uint {
 	var total uint
-	for _, details := range u.Billable {
+	for _, ms := range u.RunnerMs() {
+		total += ms
+	}
+	return total
+}
+
+// RunnerMs returns the milliseconds for each runner environment (e.g. UBUNTU, MACOS, WINDOWS)
+func (u *Usage) RunnerMs() map[string]uint {
+	if u == nil {
+		return map[string]uint{}
+	}
+	runners := make(map[string]uint, len(u.Billable))
+	for env, details := range u.Billable {
 		if details != nil {
-			total += details.TotalMs
+			runners[env] = details.TotalMs
 		}
 	}
-	return total
+	return runners
 }
 
 // Repository represents a GitHub Repository
@@ -128,6 +262,24 @@ type Repository struct {
 	Name     string
 	ID       uint
 	Private  bool `json:"private"`
+	Archived bool `json:"archived"`
+	Fork     bool `json:"fork"`
+	// Visibility is public, private or internal; it's missing from some older API responses
+	Visibility string   `json:"visibility"`
+	Topics     []string `json:"topics"`
+}
+
+// EffectiveVisibility returns the visibility of the repository, falling back on whether it's private
+// if the visibility is missing
+func (r *Repository) EffectiveVisibility() string {
+	switch {
+	case r.Visibility != "":
+		return r.Visibility
+	case r.Private:
+		return "private"
+	default:
+		return "public"
+	}
 }
 
 // User represents a GitHub User that can act as the Owner of a GitHub Repository, which might be an Organization
@@ -138,9 +290,9 @@ type User struct {
 }
 
 // GetRepository gets a Repository instance corresponding to the specified fullName
-func (c *Client) GetRepository(fullName string) (*Repository, error) {
+func (c *Client) GetRepository(ctx context.Context, fullName string) (*Repository, error) {
 	response := Repository{}
-	err := c.Rest.Get("repos/"+fullName, &response)
+	err := c.Rest.DoWithContext(ctx, http.MethodGet, "repos/"+fullName, nil, &response)
 	if err != nil {
 		if is404(err) {
 			return nil, nil
@@ -150,18 +302,19 @@ func (c *Client) GetRepository(fullName string) (*Repository, error) {
 	return &response, nil
 }
 
-// GetCurrentRepository gets the Repository that corresponds to the current working directory, or nil if there is none
-func (c *Client) GetCurrentRepository() (*Repository, error) {
+// GetCurrentRepository gets the Repository that corresponds to the current working directory, or nil if there is none;
+// the repository must be on the client's host
+func (c *Client) GetCurrentRepository(ctx context.Context) (*Repository, error) {
 	repo, err := gh.CurrentRepository()
 	if err != nil {
 		return nil, fmt.Errorf("could not get current repository: %w", err)
 	}
 
-	if repo.Host() != "github.com" {
+	if !c.IsHost(repo.Host()) {
 		return nil, UnexpectedHostError(repo.Host())
 	}
 
-	return c.GetRepository(fmt.Sprintf("%s/%s", repo.Owner(), repo.Name()))
+	return c.GetRepository(ctx, fmt.Sprintf("%s/%s", repo.Owner(), repo.Name()))
 }
 
 func is404(err error) bool {
@@ -169,10 +322,15 @@ func is404(err error) bool {
 	return errors.As(err, &httpError) && httpError.StatusCode == http.StatusNotFound
 }
 
+func isForbidden(err error) bool {
+	var httpError api.HTTPError
+	return errors.As(err, &httpError) && httpError.StatusCode == http.StatusForbidden
+}
+
 // GetUser returns a User corresponding to the specified name, or nil if the user was not found
-func (c *Client) GetUser(name string) (*User, error) {
+func (c *Client) GetUser(ctx context.Context, name string) (*User, error) {
 	response := User{}
-	err := c.Rest.Get("users/"+name, &response)
+	err := c.Rest.DoWithContext(ctx, http.MethodGet, "users/"+name, nil, &response)
 	if err != nil {
 		if is404(err) {
 			return nil, nil
@@ -183,47 +341,65 @@ func (c *Client) GetUser(name string) (*User, error) {
 }
 
 // GetAllRepositories returns a list of repositories for the specified user
-func (c *Client) GetAllRepositories(user *User) ([]*Repository, error) {
-	var page uint8 = 1
-	var repos = make([]*Repository, 0)
+func (c *Client) GetAllRepositories(ctx context.Context, user *User) ([]*Repository, error) {
+	path, err := c.getAllRepositoriesPath(user)
+	if err != nil {
+		return nil, err
+	}
 
-	for {
-		path, err := c.getAllRepositoriesPath(user, page)
-		if err != nil {
-			return nil, err
-		}
-		rp, err := c.getAllRepositoriesPage(path)
-		if err != nil {
-			return nil, err
-		}
-		if len(rp) == 0 {
-			break
+	var repos = make([]*Repository, 0)
+	err = Paginate(ctx, c, path, func(page []*Repository) {
+		repos = append(repos, page...)
+	})
+	if err != nil {
+		if is404(err) {
+			return repos, nil
 		}
-		repos = append(repos, rp...)
-		page++
+		return nil, fmt.Errorf("could not get repositories: %w", err)
 	}
 	return repos, nil
 }
 
-func (c *Client) getAllRepositoriesPath(user *User, page uint8) (string, error) {
+func (c *Client) getAllRepositoriesPath(user *User) (string, error) {
 	switch user.Type {
 	case "Organization":
-		return fmt.Sprintf("orgs/%s/repos?page=%d", user.Login, page), nil
+		return fmt.Sprintf("orgs/%s/repos", user.Login), nil
 	case "User":
-		return fmt.Sprintf("users/%s/repos?page=%d", user.Login, page), nil
+		return fmt.Sprintf("users/%s/repos", user.Login), nil
 	default:
 		return "", UnexpectedUserTypeError(user.Type)
 	}
 }
 
-func (c *Client) getAllRepositoriesPage(pagePath string) ([]*Repository, error) {
-	var response []*Repository
-	err := c.Rest.Get(pagePath, &response)
+// Billing is the Actions billing summary for an organization or user in the current billing period
+type Billing struct {
+	TotalMinutesUsed     uint            `json:"total_minutes_used"`
+	TotalPaidMinutesUsed float64         `json:"total_paid_minutes_used"`
+	IncludedMinutes      uint            `json:"included_minutes"`
+	MinutesUsedBreakdown map[string]uint `json:"minutes_used_breakdown"`
+}
+
+// GetBilling returns the Actions billing summary for an organization or user, or nil if it isn't available.
+// Only organization owners and billing managers, or the user themselves, can see the billing summary; GitHub
+// answers anyone else with a 404 or a 403, depending on the owner.
+func (c *Client) GetBilling(ctx context.Context, user *User) (*Billing, error) {
+	var path string
+	switch user.Type {
+	case "Organization":
+		path = fmt.Sprintf("orgs/%s/settings/billing/actions", user.Login)
+	case "User":
+		path = fmt.Sprintf("users/%s/settings/billing/actions", user.Login)
+	default:
+		return nil, UnexpectedUserTypeError(user.Type)
+	}
+
+	response := Billing{}
+	err := c.Rest.DoWithContext(ctx, http.MethodGet, path, nil, &response)
 	if err != nil {
-		if is404(err) {
+		if is404(err) || isForbidden(err) {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("could not get repositories: %w", err)
+		return nil, fmt.Errorf("could not get billing: %w", err)
 	}
-	return response, nil
+	return &response, nil
 }
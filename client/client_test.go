@@ -2,6 +2,7 @@ package client
 
 import (
 	"encoding/json"
+	"net/http"
 	"net/url"
 	"testing"
 
@@ -18,17 +19,17 @@ func TestClient_GetRepository(t *testing.T) {
 	// Given
 	rest, client := getTestClient()
 	expectedName := testRepoFullName
-	rest.On("Get", "repos/"+testRepoFullName, mock.Anything).
+	rest.On("DoWithContext", mock.Anything, "GET", "repos/"+testRepoFullName, nil, mock.Anything).
 		Return(nil).
 		Run(func(args mock.Arguments) {
-			repo := args.Get(1).(*Repository)
+			repo := args.Get(4).(*Repository)
 			repo.ID = 1
 			repo.Name = "gh-actions-usage"
 			repo.FullName = testRepoFullName
 		})
 
 	// When
-	repo, err := client.GetRepository(expectedName)
+	repo, err := client.GetRepository(t.Context(), expectedName)
 
 	// Then
 	require.NoError(t, err)
@@ -40,7 +41,7 @@ func TestClient_GetRepository_NotFound(t *testing.T) {
 	rest, client := getTestClient()
 	expectedName := testRepoFullName
 	requestURL, _ := url.Parse("https://github.com/" + testRepoFullName)
-	rest.On("Get", "repos/"+testRepoFullName, mock.Anything).
+	rest.On("DoWithContext", mock.Anything, "GET", "repos/"+testRepoFullName, nil, mock.Anything).
 		Return(api.HTTPError{
 			Errors:     nil,
 			Headers:    nil,
@@ -50,7 +51,7 @@ func TestClient_GetRepository_NotFound(t *testing.T) {
 		})
 
 	// When
-	repo, err := client.GetRepository(expectedName)
+	repo, err := client.GetRepository(t.Context(), expectedName)
 
 	// Then
 	require.NoError(t, err)
@@ -62,7 +63,7 @@ func TestClient_GetRepository_Failure(t *testing.T) {
 	rest, client := getTestClient()
 	expectedName := testRepoFullName
 	requestURL, _ := url.Parse("https://github.com/" + testRepoFullName)
-	rest.On("Get", "repos/"+testRepoFullName, mock.Anything).
+	rest.On("DoWithContext", mock.Anything, "GET", "repos/"+testRepoFullName, nil, mock.Anything).
 		Return(api.HTTPError{
 			Errors:     nil,
 			Headers:    nil,
@@ -72,7 +73,7 @@ func TestClient_GetRepository_Failure(t *testing.T) {
 		})
 
 	// When
-	repo, err := client.GetRepository(expectedName)
+	repo, err := client.GetRepository(t.Context(), expectedName)
 
 	// Then
 	require.Error(t, err)
@@ -83,27 +84,36 @@ func TestClient_GetWorkflows(t *testing.T) {
 	// Given
 	rest, client := getTestClient()
 	repo := Repository{ID: 1, Name: "gh-actions-usage", FullName: testRepoFullName}
-	rest.On("Get", "repos/"+testRepoFullName+"/actions/workflows?page=1", mock.Anything).
-		Return(nil).
-		Run(func(args mock.Arguments) {
-			wp := args.Get(1).(*workflowPage)
-			wp.Workflows = append(wp.Workflows, Workflow{ID: 1, Name: "Build", Path: ".github/workflows/build.yml", State: "active"})
-			wp.TotalCount = 1
-		})
-	rest.On("Get", "repos/"+testRepoFullName+"/actions/workflows?page=2", mock.Anything).
-		Return(nil).
-		Run(func(args mock.Arguments) {
-			wp := args.Get(1).(*workflowPage)
-			wp.TotalCount = 0
-		})
+	nextPage := "https://api.github.com/repositories/1/actions/workflows?per_page=100&page=2"
+	rest.On("RequestWithContext", mock.Anything, "GET", "repos/"+testRepoFullName+"/actions/workflows?per_page=100", nil).
+		Return(mocks.JSONResponse(`{"total_count":2,"workflows":[{"id":1,"name":"Build","path":".github/workflows/build.yml","state":"active"}]}`, nextPage), nil)
+	rest.On("RequestWithContext", mock.Anything, "GET", nextPage, nil).
+		Return(mocks.JSONResponse(`{"total_count":2,"workflows":[{"id":2,"name":"Release","path":".github/workflows/release.yml","state":"active"}]}`, ""), nil)
 
 	// When
-	repos, err := client.GetWorkflows(repo)
+	repos, err := client.GetWorkflows(t.Context(), repo)
 
 	// Then
 	require.NoError(t, err)
-	assert.Len(t, repos, 1)
+	assert.Len(t, repos, 2)
 	assert.Equal(t, "Build", repos[0].Name)
+	assert.Equal(t, "Release", repos[1].Name)
+	rest.AssertNumberOfCalls(t, "RequestWithContext", 2)
+}
+
+func TestClient_GetWorkflows_Failure(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	repo := Repository{ID: 1, Name: "gh-actions-usage", FullName: testRepoFullName}
+	rest.On("RequestWithContext", mock.Anything, "GET", "repos/"+testRepoFullName+"/actions/workflows?per_page=100", nil).
+		Return(nil, api.HTTPError{StatusCode: 403, Message: "Forbidden"})
+
+	// When
+	workflows, err := client.GetWorkflows(t.Context(), repo)
+
+	// Then
+	require.Error(t, err)
+	assert.Nil(t, workflows)
 }
 
 func TestClient_GetWorkflowUsage(t *testing.T) {
@@ -111,10 +121,10 @@ func TestClient_GetWorkflowUsage(t *testing.T) {
 	rest, client := getTestClient()
 	repo := Repository{ID: 1, Name: "gh-actions-usage", FullName: testRepoFullName}
 	flow := Workflow{ID: 2, Name: "CI", Path: "repos/" + testRepoFullName + "/actions/workflows/2", State: "active"}
-	rest.On("Get", "repos/"+testRepoFullName+"/actions/workflows/2/timing", mock.Anything).
+	rest.On("DoWithContext", mock.Anything, "GET", "repos/"+testRepoFullName+"/actions/workflows/2/timing", nil, mock.Anything).
 		Return(nil).
 		Run(func(args mock.Arguments) {
-			u := args.Get(1).(*Usage)
+			u := args.Get(4).(*Usage)
 			u.Billable = map[string]*UsageDetails{
 				"WINDOWS": {TotalMs: 4},
 				"UBUNTU":  {TotalMs: 180},
@@ -123,7 +133,7 @@ func TestClient_GetWorkflowUsage(t *testing.T) {
 		})
 
 	// When
-	usage, err := client.GetWorkflowUsage(repo, flow)
+	usage, err := client.GetWorkflowUsage(t.Context(), repo, flow)
 
 	// Then
 	require.NoError(t, err)
@@ -150,21 +160,35 @@ func TestUsage_TotalMs_AdditionalRunnerTypes(t *testing.T) {
 	assert.Equal(t, uint(480000), u.TotalMs())
 }
 
+func TestUsage_RunnerMs(t *testing.T) {
+	data := `{"billable":{"UBUNTU":{"total_ms":180000},"MACOS":{"total_ms":240000},"WINDOWS":{"total_ms":0}}}`
+	var u Usage
+	err := json.Unmarshal([]byte(data), &u)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]uint{"UBUNTU": 180000, "MACOS": 240000, "WINDOWS": 0}, u.RunnerMs())
+}
+
+func TestUsage_RunnerMs_Nil(t *testing.T) {
+	var u *Usage
+	assert.Empty(t, u.RunnerMs())
+	assert.Equal(t, uint(0), u.TotalMs())
+}
+
 // Straightforward Test
 func TestClient_GetUser(t *testing.T) {
 	// Given
 	rest, client := getTestClient()
-	rest.On("Get", "users/codiform", mock.Anything).
+	rest.On("DoWithContext", mock.Anything, "GET", "users/codiform", nil, mock.Anything).
 		Return(nil).
 		Run(func(args mock.Arguments) {
-			u := args.Get(1).(*User)
+			u := args.Get(4).(*User)
 			u.ID = 103469606
 			u.Login = "codiform"
 			u.Type = "Organization"
 		})
 
 	// When
-	owner, err := client.GetUser("codiform")
+	owner, err := client.GetUser(t.Context(), "codiform")
 
 	// Then
 	require.NoError(t, err)
@@ -177,7 +201,7 @@ func TestClient_GetUser_NotFound(t *testing.T) {
 	rest, client := getTestClient()
 	expectedName := "codiform2"
 	requestURL, _ := url.Parse("https://github.com/users/codiform2")
-	rest.On("Get", "users/codiform2", mock.Anything).
+	rest.On("DoWithContext", mock.Anything, "GET", "users/codiform2", nil, mock.Anything).
 		Return(api.HTTPError{
 			Errors:     nil,
 			Headers:    nil,
@@ -187,7 +211,7 @@ func TestClient_GetUser_NotFound(t *testing.T) {
 		})
 
 	// When
-	repo, err := client.GetUser(expectedName)
+	repo, err := client.GetUser(t.Context(), expectedName)
 
 	// Then
 	require.NoError(t, err)
@@ -198,18 +222,12 @@ func TestClient_GetUser_NotFound(t *testing.T) {
 func TestClient_GetAllRepositories(t *testing.T) {
 	// Given
 	rest, client := getTestClient()
-	rest.On("Get", "users/geoffreywiseman/repos?page=1", mock.Anything).
-		Return(nil).
-		Run(func(args mock.Arguments) {
-			ars := args.Get(1).(*[]*Repository)
-			*ars = append(*ars, &Repository{ID: 427462569, Name: "gh-actuse", FullName: "geoffreywiseman/gh-actuse"})
-		})
-	rest.On("Get", "users/geoffreywiseman/repos?page=2", mock.Anything).
-		Return(nil) //.
+	rest.On("RequestWithContext", mock.Anything, "GET", "users/geoffreywiseman/repos?per_page=100", nil).
+		Return(mocks.JSONResponse(`[{"id":427462569,"name":"gh-actuse","full_name":"geoffreywiseman/gh-actuse"}]`, ""), nil)
 	owner := &User{ID: 49935, Login: "geoffreywiseman", Type: "User"}
 
 	// When
-	repos, err := client.GetAllRepositories(owner)
+	repos, err := client.GetAllRepositories(t.Context(), owner)
 
 	// Then
 	require.NoError(t, err)
@@ -217,9 +235,147 @@ func TestClient_GetAllRepositories(t *testing.T) {
 	if len(repos) > 0 {
 		assert.Equal(t, "gh-actuse", repos[0].Name)
 	}
+	rest.AssertNumberOfCalls(t, "RequestWithContext", 1)
+}
+
+func TestClient_GetAllRepositories_Organization(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	nextPage := "https://api.github.com/organizations/103469606/repos?per_page=100&page=2"
+	rest.On("RequestWithContext", mock.Anything, "GET", "orgs/codiform/repos?per_page=100", nil).
+		Return(mocks.JSONResponse(`[{"id":1,"name":"gh-actions-usage","full_name":"codiform/gh-actions-usage"}]`, nextPage), nil)
+	rest.On("RequestWithContext", mock.Anything, "GET", nextPage, nil).
+		Return(mocks.JSONResponse(`[{"id":2,"name":"terraform-tools","full_name":"codiform/terraform-tools"}]`, ""), nil)
+	owner := &User{ID: 103469606, Login: "codiform", Type: "Organization"}
+
+	// When
+	repos, err := client.GetAllRepositories(t.Context(), owner)
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, repos, 2)
+	assert.Equal(t, "codiform/terraform-tools", repos[1].FullName)
+}
+
+func TestClient_GetAllRepositories_UnexpectedUserType(t *testing.T) {
+	// Given
+	_, client := getTestClient()
+	owner := &User{Login: "dependabot", Type: "Bot"}
+
+	// When
+	repos, err := client.GetAllRepositories(t.Context(), owner)
+
+	// Then
+	require.ErrorIs(t, err, UnexpectedUserTypeError("Bot"))
+	assert.Nil(t, repos)
+}
+
+func TestNextPage(t *testing.T) {
+	type test struct {
+		name string
+		link string
+		next string
+	}
+	tests := []test{
+		{name: "none", link: "", next: ""},
+		{name: "next and last", link: `<https://api.github.com/x?page=2>; rel="next", <https://api.github.com/x?page=5>; rel="last"`, next: "https://api.github.com/x?page=2"},
+		{name: "last page", link: `<https://api.github.com/x?page=1>; rel="first", <https://api.github.com/x?page=4>; rel="prev"`, next: ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := make(http.Header)
+			header.Set("Link", tc.link)
+			assert.Equal(t, tc.next, nextPage(header))
+		})
+	}
+}
+
+func TestWithPerPage(t *testing.T) {
+	assert.Equal(t, "orgs/codiform/repos?per_page=100", withPerPage("orgs/codiform/repos"))
+	assert.Equal(t, "repos/a/b/actions/runs?status=completed&per_page=100", withPerPage("repos/a/b/actions/runs?status=completed"))
+}
+
+func TestClient_GetBilling_Organization(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	rest.On("DoWithContext", mock.Anything, "GET", "orgs/codiform/settings/billing/actions", nil, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			data := `{"total_minutes_used":305,"total_paid_minutes_used":0,"included_minutes":3000,"minutes_used_breakdown":{"UBUNTU":205,"MACOS":10,"WINDOWS":90}}`
+			require.NoError(t, json.Unmarshal([]byte(data), args.Get(4)))
+		})
+	owner := &User{ID: 103469606, Login: "codiform", Type: "Organization"}
+
+	// When
+	billing, err := client.GetBilling(t.Context(), owner)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, &Billing{
+		TotalMinutesUsed:     305,
+		IncludedMinutes:      3000,
+		MinutesUsedBreakdown: map[string]uint{"UBUNTU": 205, "MACOS": 10, "WINDOWS": 90},
+	}, billing)
+}
+
+func TestClient_GetBilling_NotFound(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	rest.On("DoWithContext", mock.Anything, "GET", "users/geoffreywiseman/settings/billing/actions", nil, mock.Anything).
+		Return(api.HTTPError{StatusCode: 404, Message: "Not Found"})
+	owner := &User{ID: 49935, Login: "geoffreywiseman", Type: "User"}
+
+	// When
+	billing, err := client.GetBilling(t.Context(), owner)
+
+	// Then
+	require.NoError(t, err)
+	assert.Nil(t, billing)
+}
+
+func TestClient_GetBilling_Forbidden(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	rest.On("DoWithContext", mock.Anything, "GET", "orgs/codiform/settings/billing/actions", nil, mock.Anything).
+		Return(api.HTTPError{StatusCode: 403, Message: "Must have admin rights"})
+	owner := &User{Login: "codiform", Type: "Organization"}
+
+	// When
+	billing, err := client.GetBilling(t.Context(), owner)
+
+	// Then
+	require.NoError(t, err)
+	assert.Nil(t, billing)
+}
+
+func TestClient_GetBilling_Error(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	rest.On("DoWithContext", mock.Anything, "GET", "orgs/codiform/settings/billing/actions", nil, mock.Anything).
+		Return(api.HTTPError{StatusCode: 500, Message: "Server Error"})
+	owner := &User{Login: "codiform", Type: "Organization"}
+
+	// When
+	billing, err := client.GetBilling(t.Context(), owner)
+
+	// Then
+	require.Error(t, err)
+	assert.Nil(t, billing)
 }
 
 func getTestClient() (*mocks.RestMock, Client) {
 	rest := new(mocks.RestMock)
 	return rest, Client{Rest: rest}
 }
+
+func TestClient_IsHost(t *testing.T) {
+	// Given
+	dotcom := Client{}
+	enterprise := Client{Host: "github.example.com"}
+
+	// Then
+	assert.True(t, dotcom.IsHost("github.com"))
+	assert.False(t, dotcom.IsHost("github.example.com"))
+	assert.True(t, enterprise.IsHost("GitHub.Example.com"))
+	assert.False(t, enterprise.IsHost("github.com"))
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PerPage is the page size requested from list endpoints, the maximum that the GitHub REST API allows
+const PerPage = 100
+
+// Paginate gets every page of a list endpoint, starting at path and following the `Link: rel="next"` header
+// from each response until there are no more pages. Each page is decoded into a new T and handed to collect.
+func Paginate[T any](ctx context.Context, c *Client, path string, collect func(page T)) error {
+	next := withPerPage(path)
+	for next != "" {
+		response, err := c.Rest.RequestWithContext(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return fmt.Errorf("could not get page: %w", err)
+		}
+		var page T
+		err = json.NewDecoder(response.Body).Decode(&page)
+		_ = response.Body.Close()
+		if err != nil {
+			return fmt.Errorf("could not decode page: %w", err)
+		}
+		collect(page)
+		next = nextPage(response.Header)
+	}
+	return nil
+}
+
+func withPerPage(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%sper_page=%d", path, separator, PerPage)
+}
+
+// nextPage finds the URL for rel="next" in a Link header, e.g.
+// `<https://api.github.com/repositories/1/actions/workflows?per_page=100&page=2>; rel="next", <...>; rel="last"`,
+// returning "" on the last page
+func nextPage(header http.Header) string {
+	for _, link := range strings.Split(header.Get("Link"), ",") {
+		target, params, found := strings.Cut(link, ";")
+		if !found {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return ""
+}
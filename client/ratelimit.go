@@ -0,0 +1,200 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 5
+	// GitHub recommends waiting at least a minute after a secondary rate limit without a Retry-After
+	secondaryBackoff = time.Minute
+	serverBackoff    = time.Second
+	// resetMargin allows for clock skew when waiting for the rate limit to reset
+	resetMargin = time.Second
+)
+
+// RateLimiter is an http.RoundTripper that keeps API requests within GitHub's rate limits. It tracks the
+// remaining quota from the X-RateLimit-* response headers and waits for the reset when it's exhausted,
+// and retries secondary rate limits and server errors with jittered exponential backoff.
+type RateLimiter struct {
+	next       http.RoundTripper
+	maxRetries int
+	now        func() time.Time
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	mu    sync.Mutex
+	stats RateLimitStats
+}
+
+// RateLimitStats describes the API quota used by the requests made through a RateLimiter
+type RateLimitStats struct {
+	// Requests is the number of requests sent, including retries
+	Requests int
+	// Retries is the number of requests that were retried after a rate limit or server error
+	Retries int
+	// Consumed is the number of requests that counted against the rate limit
+	Consumed int
+	// Limit, Remaining and Reset are from the most recent response with rate limit headers
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that sends requests using next
+func NewRateLimiter(next http.RoundTripper) *RateLimiter {
+	return &RateLimiter{
+		next:       next,
+		maxRetries: defaultMaxRetries,
+		now:        time.Now,
+		sleep:      sleepContext,
+		stats:      RateLimitStats{Remaining: -1},
+	}
+}
+
+// Stats returns the quota used so far
+func (rl *RateLimiter) Stats() RateLimitStats {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.stats
+}
+
+// RoundTrip sends the request, waiting for the rate limit to reset first if the quota is exhausted,
+// and retrying rate-limited or failed requests while it can
+func (rl *RateLimiter) RoundTrip(request *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if err := rl.waitForReset(request.Context()); err != nil {
+			return nil, err
+		}
+		response, err := rl.next.RoundTrip(request)
+		if err != nil {
+			return nil, err //nolint:wrapcheck // the transport's errors are returned unchanged
+		}
+		rl.record(response)
+
+		delay, retry := rl.retryDelay(response, attempt)
+		if !retry || attempt >= rl.maxRetries || !canRetry(request) {
+			return response, nil
+		}
+		_ = response.Body.Close()
+		if delay > 0 {
+			if err := rl.sleep(request.Context(), delay); err != nil {
+				return nil, err
+			}
+		}
+		if request, err = rewind(request); err != nil {
+			return nil, err
+		}
+		rl.mu.Lock()
+		rl.stats.Retries++
+		rl.mu.Unlock()
+	}
+}
+
+// waitForReset sleeps until the rate limit resets if the last response said there were no requests remaining
+func (rl *RateLimiter) waitForReset(ctx context.Context) error {
+	rl.mu.Lock()
+	exhausted := rl.stats.Remaining == 0
+	wait := rl.stats.Reset.Sub(rl.now()) + resetMargin
+	rl.mu.Unlock()
+	if !exhausted || wait <= resetMargin {
+		return nil
+	}
+	return rl.sleep(ctx, wait)
+}
+
+// record updates the stats from the rate limit headers of a response
+func (rl *RateLimiter) record(response *http.Response) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.stats.Requests++
+	remaining, err := strconv.Atoi(response.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	rl.stats.Remaining = remaining
+	if limit, err := strconv.Atoi(response.Header.Get("X-RateLimit-Limit")); err == nil {
+		rl.stats.Limit = limit
+	}
+	if reset, err := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		rl.stats.Reset = time.Unix(reset, 0)
+	}
+	if response.StatusCode != http.StatusNotModified {
+		rl.stats.Consumed++
+	}
+}
+
+// retryDelay decides whether a response should be retried, and how long to wait first
+func (rl *RateLimiter) retryDelay(response *http.Response, attempt int) (time.Duration, bool) {
+	switch {
+	case response.StatusCode == http.StatusForbidden || response.StatusCode == http.StatusTooManyRequests:
+		if retryAfter, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+			return time.Duration(retryAfter) * time.Second, true
+		}
+		if response.Header.Get("X-RateLimit-Remaining") == "0" {
+			// the retry waits for the reset before it's sent
+			return 0, true
+		}
+		if isSecondaryRateLimit(response) {
+			return backoff(secondaryBackoff, attempt), true
+		}
+		return 0, false
+	case response.StatusCode >= http.StatusInternalServerError:
+		return backoff(serverBackoff, attempt), true
+	default:
+		return 0, false
+	}
+}
+
+// isSecondaryRateLimit checks the body of a 403 or 429 for GitHub's secondary rate limit message, leaving the
+// body intact for the caller
+func isSecondaryRateLimit(response *http.Response) bool {
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	return err == nil && strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+}
+
+// backoff doubles the base delay for each attempt, adding up to 50% jitter so that concurrent
+// requests don't all retry at once
+func backoff(base time.Duration, attempt int) time.Duration {
+	delay := base << attempt
+	return delay + rand.N(delay/2+1) //nolint:gosec // jitter doesn't need a secure random source
+}
+
+// canRetry returns true if the request can be sent again, which requires that its body can be replayed
+func canRetry(request *http.Request) bool {
+	return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+func rewind(request *http.Request) (*http.Request, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return request, nil
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("could not replay request body: %w", err)
+	}
+	retry := request.Clone(request.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stopped waiting for rate limit: %w", ctx.Err())
+	}
+}
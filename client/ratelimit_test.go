@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+// scriptedTransport returns its responses in order, one per request
+type scriptedTransport struct {
+	responses []*http.Response
+	requests  int
+	sent      []*http.Request
+}
+
+func (st *scriptedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response := st.responses[st.requests]
+	st.requests++
+	st.sent = append(st.sent, request)
+	response.Request = request
+	return response, nil
+}
+
+func testResponse(status int, remaining int, body string, headers ...string) *http.Response {
+	header := make(http.Header)
+	header.Set("X-RateLimit-Limit", "5000")
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(testNow.Add(10*time.Minute).Unix(), 10))
+	for i := 0; i+1 < len(headers); i += 2 {
+		header.Set(headers[i], headers[i+1])
+	}
+	return &http.Response{StatusCode: status, Header: header, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+func getTestLimiter(responses ...*http.Response) (*RateLimiter, *scriptedTransport, *[]time.Duration) {
+	transport := &scriptedTransport{responses: responses}
+	limiter := NewRateLimiter(transport)
+	limiter.now = func() time.Time { return testNow }
+	sleeps := make([]time.Duration, 0)
+	limiter.sleep = func(_ context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return limiter, transport, &sleeps
+}
+
+func sendTestRequest(t *testing.T, limiter *RateLimiter) *http.Response {
+	t.Helper()
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://api.github.com/users/codiform", nil)
+	require.NoError(t, err)
+	response, err := limiter.RoundTrip(request)
+	require.NoError(t, err)
+	return response
+}
+
+func TestRateLimiter_TracksQuota(t *testing.T) {
+	// Given
+	limiter, _, sleeps := getTestLimiter(
+		testResponse(http.StatusOK, 4999, "{}"),
+		testResponse(http.StatusNotModified, 4999, ""),
+		testResponse(http.StatusOK, 4998, "{}"),
+	)
+
+	// When
+	for range 3 {
+		sendTestRequest(t, limiter)
+	}
+
+	// Then
+	stats := limiter.Stats()
+	assert.Equal(t, 3, stats.Requests)
+	assert.Equal(t, 2, stats.Consumed)
+	assert.Equal(t, 0, stats.Retries)
+	assert.Equal(t, 4998, stats.Remaining)
+	assert.Equal(t, 5000, stats.Limit)
+	assert.Equal(t, testNow.Add(10*time.Minute), stats.Reset.UTC())
+	assert.Empty(t, *sleeps)
+}
+
+func TestRateLimiter_WaitsForReset(t *testing.T) {
+	// Given
+	limiter, transport, sleeps := getTestLimiter(
+		testResponse(http.StatusOK, 0, "{}"),
+		testResponse(http.StatusOK, 4999, "{}"),
+	)
+
+	// When
+	sendTestRequest(t, limiter)
+	sendTestRequest(t, limiter)
+
+	// Then
+	assert.Equal(t, 2, transport.requests)
+	assert.Equal(t, []time.Duration{10*time.Minute + resetMargin}, *sleeps)
+}
+
+func TestRateLimiter_PrimaryLimitExceeded(t *testing.T) {
+	// Given
+	limiter, _, sleeps := getTestLimiter(
+		testResponse(http.StatusForbidden, 0, `{"message":"API rate limit exceeded"}`),
+		testResponse(http.StatusOK, 4999, "{}"),
+	)
+
+	// When
+	response := sendTestRequest(t, limiter)
+
+	// Then
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []time.Duration{10*time.Minute + resetMargin}, *sleeps)
+	assert.Equal(t, 1, limiter.Stats().Retries)
+}
+
+func TestRateLimiter_RetryAfter(t *testing.T) {
+	// Given
+	limiter, _, sleeps := getTestLimiter(
+		testResponse(http.StatusTooManyRequests, 100, `{"message":"You have exceeded a secondary rate limit"}`, "Retry-After", "30"),
+		testResponse(http.StatusOK, 99, "{}"),
+	)
+
+	// When
+	response := sendTestRequest(t, limiter)
+
+	// Then
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []time.Duration{30 * time.Second}, *sleeps)
+}
+
+func TestRateLimiter_SecondaryLimitBackoff(t *testing.T) {
+	// Given
+	secondary := `{"message":"You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`
+	limiter, _, sleeps := getTestLimiter(
+		testResponse(http.StatusForbidden, 100, secondary),
+		testResponse(http.StatusForbidden, 100, secondary),
+		testResponse(http.StatusOK, 99, "{}"),
+	)
+
+	// When
+	response := sendTestRequest(t, limiter)
+
+	// Then
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	require.Len(t, *sleeps, 2)
+	assert.GreaterOrEqual(t, (*sleeps)[0], time.Minute)
+	assert.Less(t, (*sleeps)[0], 90*time.Second+time.Nanosecond)
+	assert.GreaterOrEqual(t, (*sleeps)[1], 2*time.Minute)
+}
+
+func TestRateLimiter_ServerErrorGivesUp(t *testing.T) {
+	// Given
+	responses := make([]*http.Response, 0)
+	for range defaultMaxRetries + 1 {
+		responses = append(responses, testResponse(http.StatusBadGateway, 100, "Bad Gateway"))
+	}
+	limiter, transport, sleeps := getTestLimiter(responses...)
+
+	// When
+	response := sendTestRequest(t, limiter)
+
+	// Then
+	assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+	assert.Equal(t, defaultMaxRetries+1, transport.requests)
+	assert.Len(t, *sleeps, defaultMaxRetries)
+}
+
+func TestRateLimiter_ForbiddenNotRetried(t *testing.T) {
+	// Given
+	limiter, transport, sleeps := getTestLimiter(
+		testResponse(http.StatusForbidden, 100, `{"message":"Resource not accessible by integration"}`),
+	)
+
+	// When
+	response := sendTestRequest(t, limiter)
+
+	// Then
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "Resource not accessible")
+	assert.Equal(t, 1, transport.requests)
+	assert.Empty(t, *sleeps)
+}
@@ -0,0 +1,252 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+)
+
+// manifestFile describes a recording, so that it can be replayed the way it was recorded
+const manifestFile = "manifest.json"
+
+// recordingManifest is when and from where a recording was made
+type recordingManifest struct {
+	Host     string    `json:"host"`
+	Recorded time.Time `json:"recorded"`
+}
+
+// recordedResponse is a response saved by a Recorder, or the API error that was returned instead
+type recordedResponse struct {
+	Method     string      `json:"method"`
+	Path       string      `json:"path"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// Message is the message of an API error
+	Message string `json:"message,omitempty"`
+}
+
+// MissingRecordingError is an error when a replayed request wasn't recorded
+type MissingRecordingError string
+
+// Error returns a formatted error message for MissingRecordingError
+func (e MissingRecordingError) Error() string {
+	return "No recorded response for " + string(e)
+}
+
+// recordingPath is the file that the response to a request is saved in
+func recordingPath(dir, method, path string) string {
+	sum := sha256.Sum256([]byte(method + " " + path))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Recorder is an api.RESTClient that saves every response from another RESTClient in a directory, so that the
+// requests can be replayed later by a Replayer without network access
+type Recorder struct {
+	rest api.RESTClient
+	dir  string
+}
+
+// NewRecorder returns a Recorder that sends requests with rest and saves the responses from host in dir
+func NewRecorder(rest api.RESTClient, dir, host string, recorded time.Time) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("could not create recording directory: %w", err)
+	}
+	data, err := json.MarshalIndent(recordingManifest{Host: host, Recorded: recorded.UTC()}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not encode recording manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), data, 0o600); err != nil {
+		return nil, fmt.Errorf("could not write recording manifest: %w", err)
+	}
+	return &Recorder{rest: rest, dir: dir}, nil
+}
+
+// RequestWithContext sends the request and saves the response, or the API error
+func (r *Recorder) RequestWithContext(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	response, err := r.rest.RequestWithContext(ctx, method, path, body)
+	var httpError api.HTTPError
+	switch {
+	case errors.As(err, &httpError):
+		r.save(recordedResponse{Method: method, Path: path, StatusCode: httpError.StatusCode, Header: httpError.Headers, Message: httpError.Message})
+		return nil, err //nolint:wrapcheck // the client's errors are returned unchanged
+	case err != nil:
+		return nil, err //nolint:wrapcheck // the client's errors are returned unchanged
+	}
+	data, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not read response: %w", err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(data))
+	r.save(recordedResponse{Method: method, Path: path, StatusCode: response.StatusCode, Header: response.Header, Body: string(data)})
+	return response, nil
+}
+
+// save writes a recorded response; responses that can't be saved are left out of the recording, and are
+// reported as missing when it's replayed
+func (r *Recorder) save(recording recordedResponse) {
+	data, err := json.MarshalIndent(recording, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(recordingPath(r.dir, recording.Method, recording.Path), data, 0o600)
+}
+
+// Request sends a request and saves the response
+func (r *Recorder) Request(method, path string, body io.Reader) (*http.Response, error) {
+	return r.RequestWithContext(context.Background(), method, path, body)
+}
+
+// DoWithContext sends a request, saves the response and decodes it into response
+func (r *Recorder) DoWithContext(ctx context.Context, method, path string, body io.Reader, response any) error {
+	return decodeResponse(r.RequestWithContext(ctx, method, path, body))(response)
+}
+
+// Do sends a request, saves the response and decodes it into response
+func (r *Recorder) Do(method, path string, body io.Reader, response any) error {
+	return r.DoWithContext(context.Background(), method, path, body, response)
+}
+
+// Delete sends a DELETE request, saves the response and decodes it into response
+func (r *Recorder) Delete(path string, response any) error {
+	return r.Do(http.MethodDelete, path, nil, response)
+}
+
+// Get sends a GET request, saves the response and decodes it into response
+func (r *Recorder) Get(path string, response any) error {
+	return r.Do(http.MethodGet, path, nil, response)
+}
+
+// Patch sends a PATCH request, saves the response and decodes it into response
+func (r *Recorder) Patch(path string, body io.Reader, response any) error {
+	return r.Do(http.MethodPatch, path, body, response)
+}
+
+// Post sends a POST request, saves the response and decodes it into response
+func (r *Recorder) Post(path string, body io.Reader, response any) error {
+	return r.Do(http.MethodPost, path, body, response)
+}
+
+// Put sends a PUT request, saves the response and decodes it into response
+func (r *Recorder) Put(path string, body io.Reader, response any) error {
+	return r.Do(http.MethodPut, path, body, response)
+}
+
+// Replayer is an api.RESTClient that serves the responses saved by a Recorder, without network access
+type Replayer struct {
+	dir string
+	// Host and Recorded describe where and when the responses were recorded
+	Host     string
+	Recorded time.Time
+}
+
+// NewReplayer returns a Replayer for the recording in dir
+func NewReplayer(dir string) (*Replayer, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("could not read recording: %w", err)
+	}
+	var manifest recordingManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("could not parse recording manifest: %w", err)
+	}
+	return &Replayer{dir: dir, Host: manifest.Host, Recorded: manifest.Recorded}, nil
+}
+
+// RequestWithContext returns the recorded response to the request, or the API error that was recorded
+func (r *Replayer) RequestWithContext(ctx context.Context, method, path string, _ io.Reader) (*http.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("request cancelled: %w", err)
+	}
+	data, err := os.ReadFile(recordingPath(r.dir, method, path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, MissingRecordingError(method + " " + path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read recorded response: %w", err)
+	}
+	var recording recordedResponse
+	if err := json.Unmarshal(data, &recording); err != nil {
+		return nil, fmt.Errorf("could not parse recorded response: %w", err)
+	}
+	if recording.Message != "" || recording.StatusCode >= http.StatusBadRequest {
+		return nil, api.HTTPError{StatusCode: recording.StatusCode, Message: recording.Message, Headers: recording.Header}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recording.StatusCode, http.StatusText(recording.StatusCode)),
+		StatusCode:    recording.StatusCode,
+		Header:        recording.Header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(recording.Body))),
+		ContentLength: int64(len(recording.Body)),
+	}, nil
+}
+
+// Request returns the recorded response to the request
+func (r *Replayer) Request(method, path string, body io.Reader) (*http.Response, error) {
+	return r.RequestWithContext(context.Background(), method, path, body)
+}
+
+// DoWithContext decodes the recorded response to the request into response
+func (r *Replayer) DoWithContext(ctx context.Context, method, path string, body io.Reader, response any) error {
+	return decodeResponse(r.RequestWithContext(ctx, method, path, body))(response)
+}
+
+// Do decodes the recorded response to the request into response
+func (r *Replayer) Do(method, path string, body io.Reader, response any) error {
+	return r.DoWithContext(context.Background(), method, path, body, response)
+}
+
+// Delete decodes the recorded response to a DELETE request into response
+func (r *Replayer) Delete(path string, response any) error {
+	return r.Do(http.MethodDelete, path, nil, response)
+}
+
+// Get decodes the recorded response to a GET request into response
+func (r *Replayer) Get(path string, response any) error {
+	return r.Do(http.MethodGet, path, nil, response)
+}
+
+// Patch decodes the recorded response to a PATCH request into response
+func (r *Replayer) Patch(path string, body io.Reader, response any) error {
+	return r.Do(http.MethodPatch, path, body, response)
+}
+
+// Post decodes the recorded response to a POST request into response
+func (r *Replayer) Post(path string, body io.Reader, response any) error {
+	return r.Do(http.MethodPost, path, body, response)
+}
+
+// Put decodes the recorded response to a PUT request into response
+func (r *Replayer) Put(path string, body io.Reader, response any) error {
+	return r.Do(http.MethodPut, path, body, response)
+}
+
+// decodeResponse returns a function that decodes the JSON body of a response the way the go-gh RESTClient does,
+// leaving response unchanged for empty (204) responses
+func decodeResponse(response *http.Response, err error) func(target any) error {
+	return func(target any) error {
+		if err != nil {
+			return err
+		}
+		defer func() { _ = response.Body.Close() }()
+		if response.StatusCode == http.StatusNoContent || target == nil {
+			return nil
+		}
+		if err := json.NewDecoder(response.Body).Decode(target); err != nil {
+			return fmt.Errorf("could not decode response: %w", err)
+		}
+		return nil
+	}
+}
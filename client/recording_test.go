@@ -0,0 +1,97 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/cli/go-gh"
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestRecorder(t *testing.T, dir string, responses ...*http.Response) Client {
+	t.Helper()
+	transport := &scriptedTransport{responses: responses}
+	rest, err := gh.RESTClient(&api.ClientOptions{Host: GitHubHost, AuthToken: "token", Transport: transport})
+	require.NoError(t, err)
+	recorder, err := NewRecorder(rest, dir, GitHubHost, testNow)
+	require.NoError(t, err)
+	return Client{Rest: recorder}
+}
+
+func getTestReplayer(t *testing.T, dir string) Client {
+	t.Helper()
+	replayer, err := NewReplayer(dir)
+	require.NoError(t, err)
+	return Client{Rest: replayer, Host: replayer.Host, RecordedAt: replayer.Recorded}
+}
+
+func TestRecording_Replay(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	recorder := getTestRecorder(t, dir,
+		testResponse(http.StatusOK, 4999, `{"id": 1, "name": "gh-actions-usage", "full_name": "codiform/gh-actions-usage"}`),
+		testResponse(http.StatusOK, 4998, `{"total_count": 1, "workflows": [{"id": 7, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active"}]}`),
+	)
+	recorded, err := recorder.GetRepository(t.Context(), testRepoFullName)
+	require.NoError(t, err)
+	_, err = recorder.GetWorkflows(t.Context(), *recorded)
+	require.NoError(t, err)
+
+	// When
+	replayer := getTestReplayer(t, dir)
+	repo, err := replayer.GetRepository(t.Context(), testRepoFullName)
+	require.NoError(t, err)
+	workflows, err := replayer.GetWorkflows(t.Context(), *repo)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, recorded, repo)
+	assert.Equal(t, []Workflow{{Name: "CI", Path: ".github/workflows/ci.yml", State: "active", ID: 7}}, workflows)
+	assert.Equal(t, GitHubHost, replayer.Host)
+	assert.True(t, testNow.Equal(replayer.RecordedAt))
+}
+
+func TestRecording_ReplayError(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	recorder := getTestRecorder(t, dir, testResponse(http.StatusForbidden, 4999, `{"message": "Resource not accessible by integration"}`, "Content-Type", "application/json"))
+	_, recordedErr := recorder.GetUser(t.Context(), "codiform")
+	require.Error(t, recordedErr)
+
+	// When
+	replayer := getTestReplayer(t, dir)
+	_, err := replayer.GetUser(t.Context(), "codiform")
+
+	// Then
+	var httpError api.HTTPError
+	require.ErrorAs(t, err, &httpError)
+	assert.Equal(t, http.StatusForbidden, httpError.StatusCode)
+	assert.Equal(t, "Resource not accessible by integration", httpError.Message)
+}
+
+func TestRecording_ReplayMissing(t *testing.T) {
+	// Given
+	dir := t.TempDir()
+	_ = getTestRecorder(t, dir)
+
+	// When
+	replayer := getTestReplayer(t, dir)
+	_, err := replayer.GetRepository(t.Context(), testRepoFullName)
+
+	// Then
+	var missing MissingRecordingError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, MissingRecordingError("GET repos/"+testRepoFullName), missing)
+}
+
+func TestNewReplayer_NotARecording(t *testing.T) {
+	// When
+	_, err := NewReplayer(t.TempDir())
+
+	// Then
+	require.Error(t, err)
+	assert.False(t, errors.As(err, new(MissingRecordingError)))
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Run is a single run of a workflow
+type Run struct {
+	ID         uint
+	Number     uint
+	Event      string
+	Branch     string
+	Actor      string
+	Status     string
+	Conclusion string
+	CreatedAt  time.Time
+}
+
+// RunUsage is the usage of a single workflow run
+type RunUsage struct {
+	Run Run
+	// DurationMs is how long the run took from start to finish, which isn't the same as its billable time
+	DurationMs uint
+	Usage      *Usage
+	// Jobs are the jobs in the run, if they were collected
+	Jobs []Job
+}
+
+type runResponse struct {
+	ID         uint      `json:"id"`
+	Number     uint      `json:"run_number"`
+	Event      string    `json:"event"`
+	Branch     string    `json:"head_branch"`
+	Status     string    `json:"status"`
+	Conclusion string    `json:"conclusion"`
+	CreatedAt  time.Time `json:"created_at"`
+	Actor      *User     `json:"actor"`
+}
+
+type runPage struct {
+	WorkflowRuns []runResponse `json:"workflow_runs"`
+}
+
+type runTiming struct {
+	Usage
+	RunDurationMs uint `json:"run_duration_ms"`
+}
+
+// BillingPeriodStart returns the start of the billing period that includes t; GitHub bills by calendar month, in UTC
+func BillingPeriodStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// GetWorkflowRuns returns the runs of a workflow that were created at or after since, newest first
+func (c *Client) GetWorkflowRuns(ctx context.Context, repository Repository, workflow Workflow, since time.Time) ([]Run, error) {
+	runs := make([]Run, 0)
+	created := url.QueryEscape(">=" + since.UTC().Format(time.RFC3339))
+	path := fmt.Sprintf("repos/%s/actions/workflows/%d/runs?created=%s", repository.FullName, workflow.ID, created)
+	err := Paginate(ctx, c, path, func(page runPage) {
+		for _, run := range page.WorkflowRuns {
+			item := Run{
+				ID:         run.ID,
+				Number:     run.Number,
+				Event:      run.Event,
+				Branch:     run.Branch,
+				Status:     run.Status,
+				Conclusion: run.Conclusion,
+				CreatedAt:  run.CreatedAt,
+			}
+			if run.Actor != nil {
+				item.Actor = run.Actor.Login
+			}
+			runs = append(runs, item)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get workflow runs: %w", err)
+	}
+	return runs, nil
+}
+
+// GetRunUsage returns the billable usage and duration of a workflow run
+func (c *Client) GetRunUsage(ctx context.Context, repository Repository, run Run) (*RunUsage, error) {
+	response := runTiming{}
+	path := fmt.Sprintf("repos/%s/actions/runs/%d/timing", repository.FullName, run.ID)
+	err := c.Rest.DoWithContext(ctx, http.MethodGet, path, nil, &response)
+	if err != nil {
+		return nil, fmt.Errorf("could not get run usage: %w", err)
+	}
+	return &RunUsage{Run: run, DurationMs: response.RunDurationMs, Usage: &response.Usage}, nil
+}
+
+// Job is a single job within a workflow run, including the runner it was assigned to
+type Job struct {
+	ID          uint      `json:"id"`
+	RunID       uint      `json:"run_id"`
+	Name        string    `json:"name"`
+	Labels      []string  `json:"labels"`
+	RunnerName  string    `json:"runner_name"`
+	RunnerGroup string    `json:"runner_group_name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type jobPage struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// DurationMs is how long the job ran, or 0 if it hasn't finished
+func (j Job) DurationMs() uint {
+	if j.StartedAt.IsZero() || j.CompletedAt.Before(j.StartedAt) {
+		return 0
+	}
+	return uint(j.CompletedAt.Sub(j.StartedAt).Milliseconds())
+}
+
+// SelfHosted returns true if the job ran on a self-hosted runner, which always has the self-hosted label
+func (j Job) SelfHosted() bool {
+	for _, label := range j.Labels {
+		if strings.EqualFold(label, "self-hosted") {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRunJobs returns the jobs from every attempt of a workflow run, since re-run attempts are billed too
+func (c *Client) GetRunJobs(ctx context.Context, repository Repository, run Run) ([]Job, error) {
+	jobs := make([]Job, 0)
+	path := fmt.Sprintf("repos/%s/actions/runs/%d/jobs?filter=all", repository.FullName, run.ID)
+	err := Paginate(ctx, c, path, func(page jobPage) {
+		jobs = append(jobs, page.Jobs...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// JobMs returns the time attributed to a job in the run: its billable duration if the run's timing has one,
+// otherwise how long it ran
+func (r *RunUsage) JobMs(job Job) uint {
+	if r.Usage != nil {
+		for _, details := range r.Usage.Billable {
+			if details == nil {
+				continue
+			}
+			for _, jobRun := range details.JobRuns {
+				if jobRun.JobID == job.ID {
+					return jobRun.DurationMs
+				}
+			}
+		}
+	}
+	return job.DurationMs()
+}
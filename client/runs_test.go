@@ -0,0 +1,117 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	mocks "github.com/geoffreywiseman/gh-actions-usage/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBillingPeriodStart(t *testing.T) {
+	// Given
+	eastern := time.FixedZone("EST", -5*60*60)
+
+	// Then
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), BillingPeriodStart(time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), BillingPeriodStart(time.Date(2026, 10, 31, 21, 0, 0, 0, eastern)))
+}
+
+func TestClient_GetWorkflowRuns(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	repo := Repository{ID: 1, Name: "gh-actions-usage", FullName: testRepoFullName}
+	workflow := Workflow{ID: 7, Name: "CI", Path: ".github/workflows/ci.yml"}
+	since := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	rest.On("RequestWithContext", mock.Anything, "GET", "repos/"+testRepoFullName+"/actions/workflows/7/runs?created=%3E%3D2026-10-01T00%3A00%3A00Z&per_page=100", nil).
+		Return(mocks.JSONResponse(`{"total_count":2,"workflow_runs":[
+			{"id":202,"run_number":12,"event":"push","head_branch":"main","status":"completed","conclusion":"success","created_at":"2026-10-02T10:00:00Z","actor":{"login":"geoffreywiseman"}},
+			{"id":201,"run_number":11,"event":"schedule","head_branch":"main","status":"in_progress","conclusion":null,"created_at":"2026-10-01T10:00:00Z"}
+		]}`, ""), nil)
+
+	// When
+	runs, err := client.GetWorkflowRuns(t.Context(), repo, workflow, since)
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, Run{
+		ID: 202, Number: 12, Event: "push", Branch: "main", Actor: "geoffreywiseman", Status: "completed", Conclusion: "success",
+		CreatedAt: time.Date(2026, 10, 2, 10, 0, 0, 0, time.UTC),
+	}, runs[0])
+	assert.Empty(t, runs[1].Actor)
+	assert.Empty(t, runs[1].Conclusion)
+}
+
+func TestClient_GetRunUsage(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	repo := Repository{ID: 1, Name: "gh-actions-usage", FullName: testRepoFullName}
+	run := Run{ID: 202, Number: 12}
+	rest.On("DoWithContext", mock.Anything, "GET", "repos/"+testRepoFullName+"/actions/runs/202/timing", nil, mock.Anything).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			body := `{"billable":{"UBUNTU":{"total_ms":180000,"jobs":2,"job_runs":[{"job_id":1,"duration_ms":60000},{"job_id":2,"duration_ms":120000}]}},"run_duration_ms":125000}`
+			if err := json.Unmarshal([]byte(body), args.Get(4)); err != nil {
+				panic(err)
+			}
+		})
+
+	// When
+	usage, err := client.GetRunUsage(t.Context(), repo, run)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, run, usage.Run)
+	assert.Equal(t, uint(125000), usage.DurationMs)
+	assert.Equal(t, uint(180000), usage.Usage.TotalMs())
+	assert.Len(t, usage.Usage.Billable["UBUNTU"].JobRuns, 2)
+}
+
+func TestClient_GetRunJobs(t *testing.T) {
+	// Given
+	rest, client := getTestClient()
+	repo := Repository{ID: 1, Name: "gh-actions-usage", FullName: testRepoFullName}
+	run := Run{ID: 202, Number: 12}
+	rest.On("RequestWithContext", mock.Anything, "GET", "repos/"+testRepoFullName+"/actions/runs/202/jobs?filter=all&per_page=100", nil).
+		Return(mocks.JSONResponse(`{"total_count":2,"jobs":[
+			{"id":1,"run_id":202,"name":"test (macos)","labels":["macos-14"],"runner_group_name":"GitHub Actions","status":"completed","conclusion":"success","started_at":"2026-10-02T10:00:00Z","completed_at":"2026-10-02T10:02:30Z"},
+			{"id":2,"run_id":202,"name":"deploy","labels":["self-hosted","linux"],"runner_group_name":"Fleet","status":"in_progress","conclusion":null,"started_at":"2026-10-02T10:03:00Z","completed_at":null}
+		]}`, ""), nil)
+
+	// When
+	jobs, err := client.GetRunJobs(t.Context(), repo, run)
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "test (macos)", jobs[0].Name)
+	assert.Equal(t, []string{"macos-14"}, jobs[0].Labels)
+	assert.Equal(t, uint(150000), jobs[0].DurationMs())
+	assert.Equal(t, "Fleet", jobs[1].RunnerGroup)
+	assert.Equal(t, uint(0), jobs[1].DurationMs())
+}
+
+func TestRunUsage_JobMs(t *testing.T) {
+	// Given
+	started := time.Date(2026, 10, 2, 10, 0, 0, 0, time.UTC)
+	billed := Job{ID: 1, StartedAt: started, CompletedAt: started.Add(time.Minute)}
+	unbilled := Job{ID: 2, StartedAt: started, CompletedAt: started.Add(2 * time.Minute)}
+	usage := RunUsage{Usage: &Usage{Billable: map[string]*UsageDetails{
+		"MACOS": {TotalMs: 65000, Jobs: 1, JobRuns: []JobRun{{JobID: 1, DurationMs: 65000}}},
+	}}}
+
+	// Then
+	assert.Equal(t, uint(65000), usage.JobMs(billed))
+	assert.Equal(t, uint(120000), usage.JobMs(unbilled))
+}
+
+func TestJob_SelfHosted(t *testing.T) {
+	assert.True(t, Job{Labels: []string{"self-hosted", "linux", "x64"}}.SelfHosted())
+	assert.True(t, Job{Labels: []string{"Self-Hosted"}}.SelfHosted())
+	assert.False(t, Job{Labels: []string{"ubuntu-latest"}}.SelfHosted())
+	assert.False(t, Job{}.SelfHosted())
+}
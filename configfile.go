@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ghconfig "github.com/cli/go-gh/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigFile is the config file in the current directory, which takes precedence over the user's
+const projectConfigFile = ".gh-actions-usage.yml"
+
+// configFile is a YAML file with the targets and options to use when they aren't on the command line
+type configFile struct {
+	// Targets are reported on when there aren't any targets on the command line
+	Targets []string `yaml:"targets"`
+	// Sets are named lists of targets, which can be used as a target with @name
+	Sets map[string][]string `yaml:"sets"`
+	// Defaults are option values by flag name, e.g. output: tsv or topic: [go, api]
+	Defaults map[string]any `yaml:"defaults"`
+}
+
+// UnknownConfigOptionError is an error when a config file has a default for an option that doesn't exist
+type UnknownConfigOptionError string
+
+// Error returns a formatted error message for UnknownConfigOptionError
+func (e UnknownConfigOptionError) Error() string {
+	return "Unknown option in config file: " + string(e)
+}
+
+// UnknownTargetSetError is an error when a target refers to a set that isn't in the config files
+type UnknownTargetSetError string
+
+// Error returns a formatted error message for UnknownTargetSetError
+func (e UnknownTargetSetError) Error() string {
+	return "Unknown target set: " + string(e)
+}
+
+// userConfigPath is the user's config file, in the gh config directory
+func userConfigPath() string {
+	return filepath.Join(ghconfig.ConfigDir(), "actions-usage", "config.yml")
+}
+
+// loadConfig reads the user's and the project's config files and applies their defaults to flags
+func loadConfig(flags *flag.FlagSet, strict bool) (configFile, error) {
+	settings, err := loadConfigFiles(userConfigPath(), projectConfigFile)
+	if err != nil {
+		return configFile{}, err
+	}
+	if err := settings.applyDefaults(flags, strict); err != nil {
+		return configFile{}, err
+	}
+	return settings, nil
+}
+
+// loadConfigFiles reads the config files that exist, in order; the targets, sets and defaults in later files
+// take precedence over earlier ones
+func loadConfigFiles(paths ...string) (configFile, error) {
+	merged := configFile{Sets: map[string][]string{}, Defaults: map[string]any{}}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return configFile{}, fmt.Errorf("could not read config file: %w", err)
+		}
+		var file configFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return configFile{}, fmt.Errorf("could not parse config file %s: %w", path, err)
+		}
+		if len(file.Targets) > 0 {
+			merged.Targets = file.Targets
+		}
+		for name, targets := range file.Sets {
+			merged.Sets[name] = targets
+		}
+		for name, value := range file.Defaults {
+			merged.Defaults[name] = value
+		}
+	}
+	return merged, nil
+}
+
+// applyDefaults sets the options that weren't on the command line to their defaults. Defaults for options that
+// flags doesn't have are an error if strict, and ignored otherwise, so that subcommands can share a config file.
+func (c configFile) applyDefaults(flags *flag.FlagSet, strict bool) error {
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	names := make([]string, 0, len(c.Defaults))
+	for name := range c.Defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if explicit[name] {
+			continue
+		}
+		if flags.Lookup(name) == nil {
+			if strict {
+				return UnknownConfigOptionError(name)
+			}
+			continue
+		}
+		for _, value := range defaultValues(c.Defaults[name]) {
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("invalid default for %s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// defaultValues converts a default from YAML to the values to set its flag to, once for each item of a list
+func defaultValues(value any) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+// expandTargets returns the targets on the command line, or the config's targets if there aren't any, replacing
+// each named set (@name) with its targets
+func (c configFile) expandTargets(args []string) ([]string, error) {
+	if len(args) == 0 {
+		args = c.Targets
+	}
+	targets := make([]string, 0, len(args))
+	for _, target := range args {
+		name, isSet := strings.CutPrefix(target, "@")
+		if !isSet {
+			targets = append(targets, target)
+			continue
+		}
+		set, ok := c.Sets[name]
+		if !ok {
+			return nil, UnknownTargetSetError(name)
+		}
+		targets = append(targets, set...)
+	}
+	return targets, nil
+}
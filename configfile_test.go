@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfigFiles(t *testing.T) {
+	// Given
+	user := writeConfig(t, `
+targets: [geoffreywiseman]
+sets:
+  platform: [codiform/api, codiform/web]
+  tools: [codiform/terraform-tools]
+defaults:
+  output: tsv
+  concurrency: 8
+`)
+	project := writeConfig(t, `
+targets: [codiform]
+sets:
+  tools: [codiform/gh-actions-usage]
+defaults:
+  output: json
+`)
+	missing := filepath.Join(t.TempDir(), "missing.yml")
+
+	// When
+	settings, err := loadConfigFiles(user, missing, project)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, []string{"codiform"}, settings.Targets)
+	assert.Equal(t, map[string][]string{
+		"platform": {"codiform/api", "codiform/web"},
+		"tools":    {"codiform/gh-actions-usage"},
+	}, settings.Sets)
+	assert.Equal(t, map[string]any{"output": "json", "concurrency": 8}, settings.Defaults)
+}
+
+func TestLoadConfigFiles_Invalid(t *testing.T) {
+	_, err := loadConfigFiles(writeConfig(t, "targets: {"))
+
+	assert.Error(t, err)
+}
+
+func TestConfigFile_ApplyDefaults(t *testing.T) {
+	// Given
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	output := flags.String("output", "human", "")
+	concurrency := flags.Int("concurrency", 4, "")
+	excludeForks := flags.Bool("exclude-forks", false, "")
+	var topics stringList
+	flags.Var(&topics, "topic", "")
+	require.NoError(t, flags.Parse([]string{"--output=json"}))
+	settings := configFile{Defaults: map[string]any{
+		"output":        "tsv",
+		"concurrency":   8,
+		"exclude-forks": true,
+		"topic":         []any{"go", "api"},
+	}}
+
+	// When
+	err := settings.applyDefaults(flags, true)
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, "json", *output)
+	assert.Equal(t, 8, *concurrency)
+	assert.True(t, *excludeForks)
+	assert.Equal(t, stringList{"go", "api"}, topics)
+}
+
+func TestConfigFile_ApplyDefaults_UnknownOption(t *testing.T) {
+	// Given
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("output", "human", "")
+	settings := configFile{Defaults: map[string]any{"concurency": 8}}
+
+	// When
+	strictErr := settings.applyDefaults(flags, true)
+	lenientErr := settings.applyDefaults(flags, false)
+
+	// Then
+	assert.Equal(t, UnknownConfigOptionError("concurency"), strictErr)
+	assert.NoError(t, lenientErr)
+}
+
+func TestConfigFile_ApplyDefaults_InvalidValue(t *testing.T) {
+	// Given
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.Int("concurrency", 4, "")
+	settings := configFile{Defaults: map[string]any{"concurrency": "lots"}}
+
+	// When
+	err := settings.applyDefaults(flags, true)
+
+	// Then
+	assert.Error(t, err)
+}
+
+func TestConfigFile_ExpandTargets(t *testing.T) {
+	// Given
+	settings := configFile{
+		Targets: []string{"@platform"},
+		Sets:    map[string][]string{"platform": {"codiform/api", "codiform/web"}},
+	}
+
+	// When
+	defaults, defaultsErr := settings.expandTargets(nil)
+	specified, specifiedErr := settings.expandTargets([]string{"geoffreywiseman", "@platform"})
+	_, unknownErr := settings.expandTargets([]string{"@tools"})
+
+	// Then
+	require.NoError(t, defaultsErr)
+	assert.Equal(t, []string{"codiform/api", "codiform/web"}, defaults)
+	require.NoError(t, specifiedErr)
+	assert.Equal(t, []string{"geoffreywiseman", "codiform/api", "codiform/web"}, specified)
+	assert.Equal(t, UnknownTargetSetError("tools"), unknownErr)
+}
@@ -0,0 +1,113 @@
+// Package cost estimates what GitHub Actions usage costs, using GitHub's per-minute rates and runner multipliers.
+package cost
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultRate is the per-minute price, in USD, of a standard Linux runner
+	DefaultRate = 0.008
+	msInMinute  = 60_000
+)
+
+// Model estimates the cost of usage. Each runner environment is billed at Rate times its multiplier
+// (1 for environments without one), unless Rates sets a per-minute price for the environment directly.
+type Model struct {
+	Rate        float64            `yaml:"rate"`
+	Multipliers map[string]float64 `yaml:"multipliers"`
+	Rates       map[string]float64 `yaml:"rates"`
+}
+
+// Default returns a Model using GitHub's published rate and minute multipliers for hosted runners
+func Default() *Model {
+	return &Model{
+		Rate: DefaultRate,
+		Multipliers: map[string]float64{
+			"UBUNTU":  1,
+			"WINDOWS": 2,
+			"MACOS":   10,
+		},
+		Rates: map[string]float64{},
+	}
+}
+
+// Load returns the Default model, with any rates or multipliers in the YAML file at path overriding the defaults
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read rates: %w", err)
+	}
+	model := Default()
+	if err := yaml.Unmarshal(data, model); err != nil {
+		return nil, fmt.Errorf("could not parse rates in %s: %w", path, err)
+	}
+	return model, nil
+}
+
+// RatePerMinute returns the per-minute price for a runner environment (e.g. UBUNTU, MACOS, WINDOWS)
+func (m *Model) RatePerMinute(env string) float64 {
+	if rate, ok := m.Rates[env]; ok {
+		return rate
+	}
+	return m.Rate * m.multiplier(env)
+}
+
+// multiplier returns how many minutes GitHub counts for each billable minute in a runner environment; 1 for
+// environments without a multiplier
+func (m *Model) multiplier(env string) float64 {
+	if multiplier, ok := m.Multipliers[env]; ok {
+		return multiplier
+	}
+	return 1
+}
+
+// Cost estimates the cost of usage across all of its runner environments
+func (m *Model) Cost(usage *client.Usage) float64 {
+	if usage == nil {
+		return 0
+	}
+	var total float64
+	for env, details := range usage.Billable {
+		total += float64(BillableMinutes(details)) * m.RatePerMinute(env)
+	}
+	return total
+}
+
+// Minutes returns the minutes that usage counts against an account's included minutes: the billable minutes in
+// each runner environment times its multiplier (e.g. 10 for macOS)
+func (m *Model) Minutes(usage *client.Usage) float64 {
+	if usage == nil {
+		return 0
+	}
+	var total float64
+	for env, details := range usage.Billable {
+		total += float64(BillableMinutes(details)) * m.multiplier(env)
+	}
+	return total
+}
+
+// BillableMinutes converts usage in one runner environment to minutes the way GitHub bills them, rounding each
+// job up to a whole minute. When the job durations aren't known (the workflow timing endpoint only reports a
+// total) the total is rounded up instead, so the estimate can be lower than the actual bill.
+func BillableMinutes(details *client.UsageDetails) uint {
+	if details == nil {
+		return 0
+	}
+	if len(details.JobRuns) == 0 {
+		return roundUpMinutes(details.TotalMs)
+	}
+	var minutes uint
+	for _, job := range details.JobRuns {
+		minutes += roundUpMinutes(job.DurationMs)
+	}
+	return minutes
+}
+
+func roundUpMinutes(ms uint) uint {
+	return (ms + msInMinute - 1) / msInMinute
+}
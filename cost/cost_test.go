@@ -0,0 +1,100 @@
+package cost
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModel_Cost(t *testing.T) {
+	// Given
+	usage := &client.Usage{Billable: map[string]*client.UsageDetails{
+		"UBUNTU":  {TotalMs: 90_000},
+		"WINDOWS": {TotalMs: 60_000},
+		"MACOS":   {TotalMs: 1},
+	}}
+
+	// When
+	estimate := Default().Cost(usage)
+
+	// Then
+	// 2 minutes at 1x, 1 minute at 2x, 1 minute at 10x
+	assert.InDelta(t, 0.112, estimate, 0.000001)
+}
+
+func TestModel_Cost_Nil(t *testing.T) {
+	assert.Zero(t, Default().Cost(nil))
+}
+
+func TestModel_Minutes(t *testing.T) {
+	// Given
+	usage := &client.Usage{Billable: map[string]*client.UsageDetails{
+		"UBUNTU":  {TotalMs: 90_000},
+		"WINDOWS": {TotalMs: 60_000},
+		"MACOS":   {TotalMs: 1},
+	}}
+
+	// When
+	minutes := Default().Minutes(usage)
+
+	// Then
+	// 2 minutes at 1x, 1 minute at 2x, 1 minute at 10x
+	assert.InDelta(t, 14.0, minutes, 0.000001)
+	assert.Zero(t, Default().Minutes(nil))
+}
+
+func TestBillableMinutes_RoundsEachJob(t *testing.T) {
+	// Given
+	details := &client.UsageDetails{
+		TotalMs: 150_000,
+		Jobs:    3,
+		JobRuns: []client.JobRun{
+			{JobID: 1, DurationMs: 30_000},
+			{JobID: 2, DurationMs: 60_000},
+			{JobID: 3, DurationMs: 60_001},
+		},
+	}
+
+	// When
+	minutes := BillableMinutes(details)
+
+	// Then
+	assert.Equal(t, uint(4), minutes)
+}
+
+func TestBillableMinutes_RoundsTotal(t *testing.T) {
+	assert.Equal(t, uint(3), BillableMinutes(&client.UsageDetails{TotalMs: 150_000}))
+	assert.Equal(t, uint(0), BillableMinutes(&client.UsageDetails{TotalMs: 0}))
+}
+
+func TestModel_RatePerMinute(t *testing.T) {
+	model := Default()
+	assert.InDelta(t, 0.008, model.RatePerMinute("UBUNTU"), 0.000001)
+	assert.InDelta(t, 0.08, model.RatePerMinute("MACOS"), 0.000001)
+	assert.InDelta(t, 0.008, model.RatePerMinute("UBUNTU_ARM"), 0.000001)
+}
+
+func TestLoad(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "rates.yml")
+	err := os.WriteFile(path, []byte("rate: 0.01\nmultipliers:\n  MACOS: 5\nrates:\n  UBUNTU_ARM: 0.005\n"), 0o600)
+	require.NoError(t, err)
+
+	// When
+	model, err := Load(path)
+
+	// Then
+	require.NoError(t, err)
+	assert.InDelta(t, 0.05, model.RatePerMinute("MACOS"), 0.000001)
+	assert.InDelta(t, 0.02, model.RatePerMinute("WINDOWS"), 0.000001)
+	assert.InDelta(t, 0.005, model.RatePerMinute("UBUNTU_ARM"), 0.000001)
+}
+
+func TestLoad_Missing(t *testing.T) {
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yml"))
+	require.Error(t, err)
+}
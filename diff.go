@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/format"
+	"github.com/geoffreywiseman/gh-actions-usage/history"
+)
+
+// UnknownSnapshotError is an error when a reference (e.g. @-1) doesn't match one of the stored snapshots
+type UnknownSnapshotError string
+
+// Error returns a formatted error message for UnknownSnapshotError
+func (e UnknownSnapshotError) Error() string {
+	return "Unknown snapshot: " + string(e)
+}
+
+// runDiff is the `diff` subcommand, which compares two saved JSON reports or stored snapshots
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	output := flags.String("output", "human", "Output format: human, TSV or JSON (machine readable)")
+	file := flags.String("history-file", "", "File of snapshots to read (default: history.jsonl in the gh config directory)")
+	hostname := flags.String("hostname", "", "Only use snapshots from this GitHub host (default: the gh host, or GH_HOST)")
+	flags.Usage = printDiffHelp
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	if _, err := loadConfig(flags, false); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Configuration: %s\n\n", err)
+		return exitError
+	}
+
+	formatter, err := format.GetFormatter(*output)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printDiffHelp()
+		return exitError
+	}
+	if flags.NArg() != 2 {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: expected two reports to compare, found %d\n\n", flags.NArg())
+		printDiffHelp()
+		return exitError
+	}
+	if *output != "json" {
+		fmt.Printf("GitHub Actions Usage Diff (%s)\n\n", getVersion())
+	}
+
+	loader := reportLoader{store: historyStore(*file), host: snapshotHost(*hostname)}
+	diff := format.Diff{}
+	if diff.Before.Usage, diff.BeforeName, err = loader.load(flags.Arg(0)); err == nil {
+		diff.After.Usage, diff.AfterName, err = loader.load(flags.Arg(1))
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading report: %s\n\n", err)
+		return exitError
+	}
+	formatter.PrintDiff(diff)
+	return exitOK
+}
+
+// reportLoader loads the usage from a saved JSON report, or a stored snapshot, reading the history at most once
+type reportLoader struct {
+	store history.Store
+	// host is the GitHub host whose snapshots are used, so that positions only count snapshots from that host
+	host      string
+	snapshots []history.Snapshot
+}
+
+// load returns the usage and a description of where it came from; ref is either a stored snapshot
+// (@1 is the oldest, @-1 the latest), a JSON report file, or - to read a JSON report from stdin
+func (l *reportLoader) load(ref string) (client.RepoUsage, string, error) {
+	if index, ok := strings.CutPrefix(ref, "@"); ok {
+		snapshot, err := l.snapshot(index)
+		if err != nil {
+			return nil, "", err
+		}
+		return snapshot.RepoUsage(), fmt.Sprintf("%s (%s)", ref, snapshot.Time.Format(time.RFC3339)), nil
+	}
+	if ref == "-" {
+		usage, err := format.ReadJSONReport(os.Stdin)
+		if err != nil {
+			return nil, "", fmt.Errorf("stdin: %w", err)
+		}
+		return usage, "stdin", nil
+	}
+
+	file, err := os.Open(ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not open report: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	usage, err := format.ReadJSONReport(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", ref, err)
+	}
+	return usage, ref, nil
+}
+
+func (l *reportLoader) snapshot(index string) (history.Snapshot, error) {
+	if l.snapshots == nil {
+		snapshots, err := l.store.Load()
+		if err != nil {
+			return history.Snapshot{}, fmt.Errorf("could not load snapshots: %w", err)
+		}
+		l.snapshots = history.ForHost(snapshots, l.host)
+	}
+	return selectSnapshot(l.snapshots, index)
+}
+
+// selectSnapshot finds a snapshot by position: 1 is the oldest and -1 the latest
+func selectSnapshot(snapshots []history.Snapshot, index string) (history.Snapshot, error) {
+	n, err := strconv.Atoi(index)
+	if err != nil || n == 0 || n > len(snapshots) || -n > len(snapshots) {
+		return history.Snapshot{}, UnknownSnapshotError(fmt.Sprintf("@%s (%d stored)", index, len(snapshots)))
+	}
+	if n < 0 {
+		return snapshots[len(snapshots)+n], nil
+	}
+	return snapshots[n-1], nil
+}
+
+func printDiffHelp() {
+	_, _ = fmt.Fprintln(os.Stderr, "USAGE: gh actions-usage diff [--output=human|tsv|json] [--history-file=path] [--hostname=host] <before> <after>\n\n"+
+		"Compares the usage in two reports, showing the change for each repository and workflow.\n\n"+
+		"Each report can be one of:\n"+
+		"- a file saved from --output=json (or - to read it from stdin)\n"+
+		"- a snapshot stored with --snapshot, by position: @1 is the oldest, @-1 the latest,\n"+
+		"  counting only the snapshots from the gh host (or --hostname)")
+}
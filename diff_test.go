@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectSnapshot(t *testing.T) {
+	// Given
+	snapshots := []history.Snapshot{
+		{Time: time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)},
+		{Time: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)},
+		{Time: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// When
+	oldest, oldestErr := selectSnapshot(snapshots, "1")
+	latest, latestErr := selectSnapshot(snapshots, "-1")
+	previous, previousErr := selectSnapshot(snapshots, "-2")
+
+	// Then
+	require.NoError(t, oldestErr)
+	require.NoError(t, latestErr)
+	require.NoError(t, previousErr)
+	assert.Equal(t, snapshots[0], oldest)
+	assert.Equal(t, snapshots[2], latest)
+	assert.Equal(t, snapshots[1], previous)
+}
+
+func TestSelectSnapshot_Unknown(t *testing.T) {
+	// Given
+	snapshots := []history.Snapshot{{Time: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}}
+
+	for _, index := range []string{"0", "2", "-2", "latest"} {
+		// When
+		_, err := selectSnapshot(snapshots, index)
+
+		// Then
+		var unknown UnknownSnapshotError
+		require.ErrorAs(t, err, &unknown, index)
+	}
+}
@@ -0,0 +1,288 @@
+// Package fake provides an in-memory source of GitHub Actions usage, for tests and demos that shouldn't
+// need the GitHub API.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// Source is an in-memory usage.UsageSource (and usage.RunSource and usage.BillingSource) holding owners,
+// repositories, workflows, runs and billing summaries that are added to it. It's safe for concurrent use.
+type Source struct {
+	mu        sync.Mutex
+	current   string
+	users     map[string]*client.User
+	repos     map[string]*client.Repository
+	owned     map[string][]*client.Repository
+	workflows map[string][]client.Workflow
+	usage     map[string]map[uint]*client.Usage
+	runs      map[string]map[uint][]*client.RunUsage
+	billing   map[string]*client.Billing
+	failures  map[string]error
+	nextID    uint
+}
+
+// New returns an empty Source
+func New() *Source {
+	return &Source{
+		users:     make(map[string]*client.User),
+		repos:     make(map[string]*client.Repository),
+		owned:     make(map[string][]*client.Repository),
+		workflows: make(map[string][]client.Workflow),
+		usage:     make(map[string]map[uint]*client.Usage),
+		runs:      make(map[string]map[uint][]*client.RunUsage),
+		billing:   make(map[string]*client.Billing),
+		failures:  make(map[string]error),
+	}
+}
+
+// SetCurrent sets the full name of the repository returned by GetCurrentRepository; empty is none
+func (s *Source) SetCurrent(fullName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = fullName
+}
+
+// AddUser adds a user or organization, depending on userType ("User" or "Organization"), returning the one
+// already added if there is one
+func (s *Source) AddUser(login, userType string) *client.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addUser(login, userType)
+}
+
+func (s *Source) addUser(login, userType string) *client.User {
+	if user, ok := s.users[login]; ok {
+		return user
+	}
+	s.nextID++
+	user := &client.User{Login: login, Type: userType, ID: s.nextID}
+	s.users[login] = user
+	return user
+}
+
+// AddRepository adds a repository by full name (e.g. codiform/gh-actions-usage), adding its owner as a user
+// if it hasn't been added; the repository's other fields (e.g. Archived or Topics) are copied from template
+func (s *Source) AddRepository(fullName string, template client.Repository) *client.Repository {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owner, name, _ := strings.Cut(fullName, "/")
+	repo := template
+	repo.Owner = s.addUser(owner, "User")
+	repo.FullName = fullName
+	repo.Name = name
+	s.nextID++
+	repo.ID = s.nextID
+	s.repos[fullName] = &repo
+	s.owned[owner] = append(s.owned[owner], &repo)
+	return &repo
+}
+
+// AddWorkflow adds a workflow to a repository with its usage in milliseconds by runner environment (e.g.
+// UBUNTU), giving it an ID if it doesn't have one
+func (s *Source) AddWorkflow(repo *client.Repository, workflow client.Workflow, runnerMs map[string]uint) client.Workflow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if workflow.ID == 0 {
+		s.nextID++
+		workflow.ID = s.nextID
+	}
+	s.workflows[repo.FullName] = append(s.workflows[repo.FullName], workflow)
+	if s.usage[repo.FullName] == nil {
+		s.usage[repo.FullName] = make(map[uint]*client.Usage)
+	}
+	s.usage[repo.FullName][workflow.ID] = billable(runnerMs)
+	return workflow
+}
+
+// AddRun adds a run of a workflow that took durationMs, with its usage in milliseconds by runner environment
+// and its jobs, giving it an ID if it doesn't have one
+func (s *Source) AddRun(repo *client.Repository, workflow client.Workflow, run client.Run, durationMs uint, runnerMs map[string]uint, jobs ...client.Job) client.Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if run.ID == 0 {
+		s.nextID++
+		run.ID = s.nextID
+	}
+	if s.runs[repo.FullName] == nil {
+		s.runs[repo.FullName] = make(map[uint][]*client.RunUsage)
+	}
+	runUsage := &client.RunUsage{Run: run, DurationMs: durationMs, Usage: billable(runnerMs), Jobs: jobs}
+	s.runs[repo.FullName][workflow.ID] = append(s.runs[repo.FullName][workflow.ID], runUsage)
+	return run
+}
+
+// SetBilling sets the billing summary of a user or organization
+func (s *Source) SetBilling(login string, billing client.Billing) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.billing[login] = &billing
+}
+
+// FailRepository makes listing the workflows of a repository fail with err
+func (s *Source) FailRepository(fullName string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[fullName] = err
+}
+
+// FailWorkflow makes getting the usage of a workflow, by path, fail with err
+func (s *Source) FailWorkflow(fullName, path string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[fullName+" "+path] = err
+}
+
+func billable(runnerMs map[string]uint) *client.Usage {
+	usage := &client.Usage{Billable: make(map[string]*client.UsageDetails, len(runnerMs))}
+	for runner, ms := range runnerMs {
+		usage.Billable[runner] = &client.UsageDetails{TotalMs: ms}
+	}
+	return usage
+}
+
+// GetCurrentRepository returns the Current repository, or nil if there is none
+func (s *Source) GetCurrentRepository(ctx context.Context) (*client.Repository, error) {
+	s.mu.Lock()
+	current := s.current
+	s.mu.Unlock()
+	if current == "" {
+		return nil, ctx.Err() //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	return s.GetRepository(ctx, current)
+}
+
+// GetRepository returns the repository with the full name, or nil if it wasn't added
+func (s *Source) GetRepository(ctx context.Context, fullName string) (*client.Repository, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.repos[fullName], nil
+}
+
+// GetUser returns the user or organization with the login, or nil if it wasn't added
+func (s *Source) GetUser(ctx context.Context, name string) (*client.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[name], nil
+}
+
+// GetAllRepositories returns the repositories owned by the user, in the order they were added
+func (s *Source) GetAllRepositories(ctx context.Context, user *client.User) ([]*client.Repository, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*client.Repository(nil), s.owned[user.Login]...), nil
+}
+
+// GetWorkflows returns the workflows of the repository, or the error it was set to fail with
+func (s *Source) GetWorkflows(ctx context.Context, repository client.Repository) ([]client.Workflow, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failures[repository.FullName]; err != nil {
+		return nil, fmt.Errorf("could not get workflows: %w", err)
+	}
+	return append([]client.Workflow(nil), s.workflows[repository.FullName]...), nil
+}
+
+// GetWorkflowUsage returns the usage of the workflow, or the error it was set to fail with
+func (s *Source) GetWorkflowUsage(ctx context.Context, repository client.Repository, workflow client.Workflow) (*client.Usage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.failures[repository.FullName+" "+workflow.Path]; err != nil {
+		return nil, fmt.Errorf("could not get workflow usage: %w", err)
+	}
+	usage, ok := s.usage[repository.FullName][workflow.ID]
+	if !ok {
+		return &client.Usage{}, nil
+	}
+	// callers add runs to the usage, which mustn't change what's stored
+	return &client.Usage{Billable: usage.Billable}, nil
+}
+
+// GetWorkflowRuns returns the runs of the workflow that were created at or after since, newest first (assuming
+// they were added oldest first)
+func (s *Source) GetWorkflowRuns(ctx context.Context, repository client.Repository, workflow client.Workflow, since time.Time) ([]client.Run, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := make([]client.Run, 0)
+	for _, runUsage := range s.runs[repository.FullName][workflow.ID] {
+		if !runUsage.Run.CreatedAt.Before(since) {
+			runs = append(runs, runUsage.Run)
+		}
+	}
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs, nil
+}
+
+// GetRunUsage returns the usage of the run, or an empty usage if it wasn't added
+func (s *Source) GetRunUsage(ctx context.Context, repository client.Repository, run client.Run) (*client.RunUsage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if runUsage := s.findRun(repository, run); runUsage != nil {
+		return &client.RunUsage{Run: runUsage.Run, DurationMs: runUsage.DurationMs, Usage: runUsage.Usage}, nil
+	}
+	return &client.RunUsage{Run: run, Usage: &client.Usage{}}, nil
+}
+
+// GetRunJobs returns the jobs of the run
+func (s *Source) GetRunJobs(ctx context.Context, repository client.Repository, run client.Run) ([]client.Job, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]client.Job, 0)
+	if runUsage := s.findRun(repository, run); runUsage != nil {
+		jobs = append(jobs, runUsage.Jobs...)
+	}
+	return jobs, nil
+}
+
+func (s *Source) findRun(repository client.Repository, run client.Run) *client.RunUsage {
+	for _, runs := range s.runs[repository.FullName] {
+		for _, runUsage := range runs {
+			if runUsage.Run.ID == run.ID {
+				return runUsage
+			}
+		}
+	}
+	return nil
+}
+
+// GetBilling returns the billing summary of the user or organization, or nil if it wasn't set
+func (s *Source) GetBilling(ctx context.Context, user *client.User) (*client.Billing, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err //nolint:wrapcheck // a cancelled context is reported as it is
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.billing[user.Login], nil
+}
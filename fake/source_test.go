@@ -0,0 +1,93 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/fake"
+	"github.com/geoffreywiseman/gh-actions-usage/usage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errGeneric = errors.New("generic error")
+
+var (
+	_ usage.UsageSource   = (*fake.Source)(nil)
+	_ usage.RunSource     = (*fake.Source)(nil)
+	_ usage.BillingSource = (*fake.Source)(nil)
+)
+
+func TestSource_Collect(t *testing.T) {
+	// Given
+	source := fake.New()
+	source.AddUser("codiform", "Organization")
+	repo := source.AddRepository("codiform/gh-actions-usage", client.Repository{})
+	source.AddRepository("codiform/legacy", client.Repository{Archived: true})
+	broken := source.AddRepository("codiform/broken", client.Repository{})
+	ci := source.AddWorkflow(repo, client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml"}, map[string]uint{"UBUNTU": 120000})
+	source.AddRun(repo, ci, client.Run{CreatedAt: time.Now().AddDate(-1, 0, 0)}, 60000, map[string]uint{"UBUNTU": 60000})
+	run := source.AddRun(repo, ci, client.Run{CreatedAt: time.Now()}, 45000, map[string]uint{"UBUNTU": 60000},
+		client.Job{Name: "build"})
+	source.SetBilling("codiform", client.Billing{TotalMinutesUsed: 3})
+	source.FailRepository(broken.FullName, errGeneric)
+	collector := usage.NewCollector(source, usage.Options{
+		Targets: []string{"codiform"},
+		Filter:  usage.RepositoryFilter{ExcludeArchived: true},
+		Jobs:    true,
+		Billing: true,
+	})
+
+	// When
+	report, err := collector.Collect(t.Context())
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, report.Usage, 1)
+	flowUsage := report.Usage[repo][ci]
+	require.NotNil(t, flowUsage)
+	assert.Equal(t, uint(120000), flowUsage.TotalMs())
+	require.Len(t, flowUsage.Runs, 1)
+	assert.Equal(t, run.ID, flowUsage.Runs[0].Run.ID)
+	assert.Equal(t, uint(45000), flowUsage.Runs[0].DurationMs)
+	require.Len(t, flowUsage.Runs[0].Jobs, 1)
+	assert.Equal(t, "build", flowUsage.Runs[0].Jobs[0].Name)
+	require.Len(t, report.Failures, 1)
+	assert.Equal(t, broken, report.Failures[0].Repository)
+	require.ErrorIs(t, report.Failures[0].Err, errGeneric)
+	assert.Equal(t, uint(3), report.Billing["codiform"].TotalMinutesUsed)
+}
+
+func TestSource_CurrentRepository(t *testing.T) {
+	// Given
+	source := fake.New()
+	repo := source.AddRepository("geoffreywiseman/gh-actuse", client.Repository{})
+
+	// When
+	none, noneErr := source.GetCurrentRepository(t.Context())
+	source.SetCurrent(repo.FullName)
+	current, currentErr := source.GetCurrentRepository(t.Context())
+
+	// Then
+	require.NoError(t, noneErr)
+	assert.Nil(t, none)
+	require.NoError(t, currentErr)
+	assert.Equal(t, repo, current)
+}
+
+func TestSource_Cancelled(t *testing.T) {
+	// Given
+	source := fake.New()
+	source.AddRepository("geoffreywiseman/gh-actuse", client.Repository{})
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	// When
+	_, err := source.GetRepository(ctx, "geoffreywiseman/gh-actuse")
+
+	// Then
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/usage"
+)
+
+// InvalidMinUsageError is an error when the minimum usage for workflows to be shown is negative
+type InvalidMinUsageError time.Duration
+
+// Error returns a formatted error message for InvalidMinUsageError
+func (e InvalidMinUsageError) Error() string {
+	return fmt.Sprintf("Invalid minimum usage: %s (must not be negative)", time.Duration(e))
+}
+
+// stringList is a flag that can be repeated, collecting each value
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds a value to the list
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// patternList is a flag that can be repeated, collecting repository name patterns
+type patternList []usage.NamePattern
+
+func (l *patternList) String() string {
+	patterns := make([]string, 0, len(*l))
+	for _, p := range *l {
+		patterns = append(patterns, p.String())
+	}
+	return strings.Join(patterns, ",")
+}
+
+// Set adds a pattern to the list, returning an error if it isn't valid
+func (l *patternList) Set(value string) error {
+	pattern, err := usage.ParseNamePattern(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, pattern)
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatternList(t *testing.T) {
+	var patterns patternList
+
+	require.NoError(t, patterns.Set("api-*"))
+	require.NoError(t, patterns.Set("/^(gh|terraform)-/"))
+
+	assert.Equal(t, "api-*,/^(gh|terraform)-/", patterns.String())
+}
+
+func TestPatternList_Invalid(t *testing.T) {
+	var patterns patternList
+
+	require.Error(t, patterns.Set("/(unclosed/"))
+	require.Error(t, patterns.Set("[unclosed"))
+	assert.Empty(t, patterns)
+}
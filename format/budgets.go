@@ -0,0 +1,47 @@
+package format
+
+import (
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
+	"github.com/geoffreywiseman/gh-actions-usage/cost"
+)
+
+// CheckBudgets compares the usage in the report with its budgets. Minute budgets use billable minutes, rounded up
+// and multiplied for each runner environment the way GitHub counts them against included minutes, and dollar
+// budgets use estimated costs; both use the report's cost model, or the default rates and multipliers if it
+// doesn't have one. Workflows that are hidden or beyond the top still count.
+func CheckBudgets(report Report) []budget.Status {
+	if report.Budgets.Empty() {
+		return nil
+	}
+	model := report.Cost
+	if model == nil {
+		model = cost.Default()
+	}
+	totals := budget.Totals{
+		Owners:       make(map[string]budget.Usage),
+		Repositories: make(map[string]budget.Usage, len(report.Usage)),
+	}
+	for repo, flowUsage := range report.Usage {
+		var repoUsage budget.Usage
+		for _, usage := range flowUsage {
+			repoUsage.Minutes += model.Minutes(usage)
+			repoUsage.Cost += model.Cost(usage)
+		}
+		totals.Repositories[repoFullName(repo)] = repoUsage
+		owner := totals.Owners[ownerName(repo)]
+		owner.Minutes += repoUsage.Minutes
+		owner.Cost += repoUsage.Cost
+		totals.Owners[ownerName(repo)] = owner
+		totals.Total.Minutes += repoUsage.Minutes
+		totals.Total.Cost += repoUsage.Cost
+	}
+	return report.Budgets.Check(totals)
+}
+
+// budgetName names what a budget limits, e.g. "all repositories", an owner or a repository
+func budgetName(status budget.Status) string {
+	if status.Scope == budget.ScopeTotal {
+		return "all repositories"
+	}
+	return status.Name
+}
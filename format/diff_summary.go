@@ -0,0 +1,153 @@
+package format
+
+import (
+	"sort"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// Statuses of a repository or workflow in a diff
+const (
+	statusNew       = "new"
+	statusRemoved   = "removed"
+	statusChanged   = "changed"
+	statusUnchanged = "unchanged"
+)
+
+const percent = 100
+
+// usageChange compares the usage of a repository, workflow or the whole report in two reports
+type usageChange struct {
+	Status string
+	Before uint
+	After  uint
+}
+
+// Delta is the change in usage, in milliseconds; it's negative if usage went down
+func (c usageChange) Delta() int64 {
+	return int64(c.After) - int64(c.Before)
+}
+
+// Percent is the change in usage as a percentage of the usage before, which is undefined (false) if there wasn't any
+func (c usageChange) Percent() (float64, bool) {
+	if c.Before == 0 {
+		return 0, false
+	}
+	return float64(c.Delta()) * percent / float64(c.Before), true
+}
+
+type workflowDiff struct {
+	// Workflow is as it was in the later report, if it's in both
+	Workflow client.Workflow
+	usageChange
+}
+
+type repoDiff struct {
+	FullName  string
+	Workflows []workflowDiff
+	usageChange
+}
+
+type diffSummary struct {
+	Repos []repoDiff
+	Total usageChange
+}
+
+// summarizeDiff lines up the repository and workflow summaries of the two reports; repositories are matched by
+// name and workflows by ID (or path, if there's no ID), so that renamed workflows are still compared
+func summarizeDiff(diff Diff) diffSummary {
+	before := summarizeUsage(diff.Before)
+	after := summarizeUsage(diff.After)
+
+	type repoPair struct {
+		before, after *repoSummary
+	}
+	pairs := make(map[string]*repoPair)
+	pair := func(name string) *repoPair {
+		p := pairs[name]
+		if p == nil {
+			p = &repoPair{}
+			pairs[name] = p
+		}
+		return p
+	}
+	for i := range before.Repos {
+		pair(before.Repos[i].Repo.FullName).before = &before.Repos[i]
+	}
+	for i := range after.Repos {
+		pair(after.Repos[i].Repo.FullName).after = &after.Repos[i]
+	}
+
+	summary := diffSummary{Repos: make([]repoDiff, 0, len(pairs)), Total: compareUsage(before.Total, true, after.Total, true)}
+	for name, p := range pairs {
+		var beforeTotal, afterTotal uint
+		var beforeWorkflows, afterWorkflows []workflowSummary
+		if p.before != nil {
+			beforeTotal, beforeWorkflows = p.before.Total, p.before.Workflows
+		}
+		if p.after != nil {
+			afterTotal, afterWorkflows = p.after.Total, p.after.Workflows
+		}
+		summary.Repos = append(summary.Repos, repoDiff{
+			FullName:    name,
+			Workflows:   diffWorkflows(name, beforeWorkflows, afterWorkflows),
+			usageChange: compareUsage(beforeTotal, p.before != nil, afterTotal, p.after != nil),
+		})
+	}
+	sort.Slice(summary.Repos, func(i, j int) bool {
+		return summary.Repos[i].FullName < summary.Repos[j].FullName
+	})
+	return summary
+}
+
+func diffWorkflows(repo string, before, after []workflowSummary) []workflowDiff {
+	type workflowPair struct {
+		workflow      client.Workflow
+		before, after *workflowSummary
+	}
+	pairs := make(map[workflowKey]*workflowPair)
+	for i := range before {
+		pairs[newWorkflowKey(repo, before[i].Workflow)] = &workflowPair{workflow: before[i].Workflow, before: &before[i]}
+	}
+	for i := range after {
+		key := newWorkflowKey(repo, after[i].Workflow)
+		p := pairs[key]
+		if p == nil {
+			p = &workflowPair{}
+			pairs[key] = p
+		}
+		p.workflow = after[i].Workflow
+		p.after = &after[i]
+	}
+
+	diffs := make([]workflowDiff, 0, len(pairs))
+	for _, p := range pairs {
+		var beforeUsage, afterUsage uint
+		if p.before != nil {
+			beforeUsage = p.before.Usage
+		}
+		if p.after != nil {
+			afterUsage = p.after.Usage
+		}
+		diffs = append(diffs, workflowDiff{Workflow: p.workflow, usageChange: compareUsage(beforeUsage, p.before != nil, afterUsage, p.after != nil)})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return workflowLess(diffs[i].Workflow, diffs[j].Workflow)
+	})
+	return diffs
+}
+
+func compareUsage(before uint, inBefore bool, after uint, inAfter bool) usageChange {
+	change := usageChange{Before: before, After: after}
+	switch {
+	case !inBefore:
+		change.Status = statusNew
+	case !inAfter:
+		change.Status = statusRemoved
+	case before != after:
+		change.Status = statusChanged
+	default:
+		change.Status = statusUnchanged
+	}
+	return change
+}
@@ -1,6 +1,11 @@
 package format
 
-import "github.com/geoffreywiseman/gh-actions-usage/client"
+import (
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/history"
+)
 
 func sampleMultipleRepositoriesUsage() client.RepoUsage {
 	codiform := &client.User{Login: "codiform"}
@@ -15,12 +20,114 @@ func sampleMultipleRepositoriesUsage() client.RepoUsage {
 
 	return client.RepoUsage{
 		firstRepo: {
-			release: 1500,
-			ci:      500,
+			release: billable(map[string]uint{"UBUNTU": 1000, "MACOS": 500}),
+			ci:      billable(map[string]uint{"UBUNTU": 500, "WINDOWS": 0}),
 		},
 		secondRepo: {
-			ci: 1000,
+			ci: billable(map[string]uint{"WINDOWS": 1000}),
 		},
 		thirdRepo: {},
 	}
 }
+
+// billable builds the usage the API would report for the specified milliseconds by runner environment
+func billable(runners map[string]uint) *client.Usage {
+	usage := &client.Usage{Billable: make(map[string]*client.UsageDetails, len(runners))}
+	for env, ms := range runners {
+		usage.Billable[env] = &client.UsageDetails{TotalMs: ms}
+	}
+	return usage
+}
+
+// sampleHistory has two snapshots a month apart, with a release workflow that only appears in the second
+func sampleHistory() []history.Snapshot {
+	repo := &client.Repository{Owner: &client.User{Login: "codiform"}, FullName: "codiform/gh-actions-usage", Private: true}
+	ci := client.Workflow{ID: 1, Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}
+	release := client.Workflow{ID: 2, Name: "Release", Path: ".github/workflows/release.yml", State: "active"}
+
+	return []history.Snapshot{
+		history.NewSnapshot(time.Date(2026, 9, 1, 12, 0, 0, 0, time.UTC), client.RepoUsage{
+			repo: {ci: billable(map[string]uint{"UBUNTU": 60000})},
+		}),
+		history.NewSnapshot(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), client.RepoUsage{
+			repo: {
+				ci:      billable(map[string]uint{"UBUNTU": 90000}),
+				release: billable(map[string]uint{"MACOS": 30000}),
+			},
+		}),
+	}
+}
+
+// sampleDiff compares two reports where one workflow grew, one was added, one was removed and one repository is gone
+func sampleDiff() Diff {
+	codiform := &client.User{Login: "codiform"}
+	ci := client.Workflow{ID: 1, Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}
+	old := client.Workflow{ID: 3, Name: "Old", Path: ".github/workflows/old.yml", State: "disabled_manually"}
+	release := client.Workflow{ID: 2, Name: "Release", Path: ".github/workflows/release.yml", State: "active"}
+	before := client.RepoUsage{
+		&client.Repository{Owner: codiform, FullName: "codiform/gh-actions-usage"}: {
+			ci:  billable(map[string]uint{"UBUNTU": 60000}),
+			old: billable(map[string]uint{"UBUNTU": 10000}),
+		},
+		&client.Repository{Owner: codiform, FullName: "codiform/terraform-tools"}: {
+			client.Workflow{ID: 4, Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}: billable(map[string]uint{"WINDOWS": 1000}),
+		},
+	}
+	after := client.RepoUsage{
+		&client.Repository{Owner: codiform, FullName: "codiform/gh-actions-usage"}: {
+			ci:      billable(map[string]uint{"UBUNTU": 90000}),
+			release: billable(map[string]uint{"MACOS": 30000}),
+		},
+	}
+	return Diff{Before: Report{Usage: before}, After: Report{Usage: after}, BeforeName: "before.json", AfterName: "after.json"}
+}
+
+// sampleRunsUsage has a CI workflow with two runs, the second of which is still in progress, and a deploy
+// workflow whose run has a job on a self-hosted runner; the runs include their jobs
+func sampleRunsUsage() client.RepoUsage {
+	started := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	job := func(id uint, name string, ms int64, labels ...string) client.Job {
+		return client.Job{ID: id, Name: name, Labels: labels, RunnerGroup: "GitHub Actions", StartedAt: started,
+			CompletedAt: started.Add(time.Duration(ms) * time.Millisecond)}
+	}
+	jobRuns := func(runs ...client.JobRun) *client.Usage {
+		details := &client.UsageDetails{JobRuns: runs}
+		for _, run := range runs {
+			details.TotalMs += run.DurationMs
+		}
+		return &client.Usage{Billable: map[string]*client.UsageDetails{"UBUNTU": details}}
+	}
+
+	ci := client.Workflow{ID: 1, Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}
+	ciUsage := billable(map[string]uint{"UBUNTU": 150000})
+	ciUsage.Runs = []*client.RunUsage{
+		{
+			Run:        client.Run{ID: 11, Number: 2, Event: "pull_request", Branch: "feature", Actor: "octocat", Status: "in_progress", CreatedAt: time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC)},
+			DurationMs: 30000,
+			Usage:      jobRuns(client.JobRun{JobID: 3, DurationMs: 30000}),
+			Jobs:       []client.Job{job(3, "build", 28000, "ubuntu-latest")},
+		},
+		{
+			Run:        client.Run{ID: 10, Number: 1, Event: "push", Branch: "main", Actor: "geoffreywiseman", Status: "completed", Conclusion: "success", CreatedAt: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)},
+			DurationMs: 90000,
+			Usage:      jobRuns(client.JobRun{JobID: 1, DurationMs: 100000}, client.JobRun{JobID: 2, DurationMs: 20000}),
+			Jobs:       []client.Job{job(1, "build", 95000, "ubuntu-latest"), job(2, "lint", 18000, "ubuntu-latest")},
+		},
+	}
+
+	deploy := client.Workflow{ID: 2, Name: "Deploy", Path: ".github/workflows/deploy.yml", State: "active"}
+	deployJob := job(4, "deploy", 300000, "self-hosted", "linux")
+	deployJob.RunnerGroup = "Fleet"
+	deployUsage := billable(map[string]uint{})
+	deployUsage.Runs = []*client.RunUsage{
+		{
+			Run:        client.Run{ID: 20, Number: 1, Event: "push", Branch: "main", Actor: "octocat", Status: "completed", Conclusion: "success", CreatedAt: time.Date(2026, 10, 3, 9, 0, 0, 0, time.UTC)},
+			DurationMs: 300000,
+			Usage:      billable(map[string]uint{}),
+			Jobs:       []client.Job{deployJob},
+		},
+	}
+
+	repo := &client.Repository{Owner: &client.User{Login: "codiform"}, FullName: "codiform/gh-actions-usage", Private: true}
+	return client.RepoUsage{repo: {ci: ciUsage, deploy: deployUsage}}
+}
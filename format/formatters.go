@@ -4,17 +4,60 @@ package format
 import (
 	"os"
 
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
 	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/cost"
+	"github.com/geoffreywiseman/gh-actions-usage/history"
 )
 
 var formatters = map[string]Formatter{
 	"human": humanFormatter{os.Stdout},
 	"tsv":   tsvFormatter{os.Stdout},
+	"json":  jsonFormatter{os.Stdout},
 }
 
 // Formatter is an interface for formatting output from the extension, allowing the user to pick one of several output styles
 type Formatter interface {
-	PrintUsage(usage client.RepoUsage)
+	PrintUsage(report Report)
+	// PrintHistory shows how usage changed across snapshots, oldest first
+	PrintHistory(snapshots []history.Snapshot)
+	// PrintDiff shows what changed from one report to another
+	PrintDiff(diff Diff)
+}
+
+// Report is the usage collected by the extension, along with the options that affect how it is summarized
+type Report struct {
+	Usage client.RepoUsage
+	// Failures are the repositories and workflows whose usage couldn't be collected
+	Failures []client.UsageError
+	// Billing is the billing summary for owners, by login, when it was requested and available
+	Billing map[string]*client.Billing
+	// Cost estimates the cost of the usage, if set
+	Cost *cost.Model
+	// Budgets are the limits to check the usage against, if any
+	Budgets *budget.Budgets
+	// Order decides how repositories and workflows are sorted, and how many workflows are shown
+	Order Order
+	// GroupBy ranks the usage by workflow, repository or owner instead of listing it by repository, if set
+	GroupBy GroupBy
+	// Workflows decides which workflows are shown; hidden workflows are still counted in the totals
+	Workflows WorkflowFilter
+	// Runs shows the runs of each workflow, if they were collected; they're also collected for the jobs, which can be
+	// shown without them
+	Runs bool
+	// SelfHosted shows how long jobs ran on self-hosted runners, which requires the jobs of each run
+	SelfHosted bool
+	// Host is the GitHub Enterprise Server host the usage came from, or empty for github.com
+	Host string
+}
+
+// Diff is a pair of reports to compare, e.g. last week's usage and this week's
+type Diff struct {
+	Before Report
+	After  Report
+	// BeforeName and AfterName describe where the reports came from, e.g. a file or snapshot
+	BeforeName string
+	AfterName  string
 }
 
 // UnknownFormatterError is an error when the specified formatter can't be found
@@ -1,8 +1,13 @@
 package format
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"testing"
 
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/geoffreywiseman/gh-actions-usage/client"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,6 +19,7 @@ func TestGetFormatters(t *testing.T) {
 	tests := []test{
 		{name: "human", expectedType: humanFormatter{}},
 		{name: "tsv", expectedType: tsvFormatter{}},
+		{name: "json", expectedType: jsonFormatter{}},
 		{name: "yaml"},
 	}
 	for _, tc := range tests {
@@ -27,3 +33,136 @@ func TestGetFormatters(t *testing.T) {
 		})
 	}
 }
+
+var errBilling = errors.New("could not get billing")
+
+func TestFormatters_Failures(t *testing.T) {
+	flaky := client.Workflow{ID: 9, Name: "Deploy", Path: ".github/workflows/deploy.yml", State: "active"}
+	report := Report{
+		Usage: client.RepoUsage{&client.Repository{Owner: &client.User{Login: "codiform"}, FullName: "codiform/gh-actions-usage", Private: true}: {}},
+		Failures: []client.UsageError{
+			{Repository: &client.Repository{FullName: "codiform/flaky"}, Workflow: &flaky, Err: api.HTTPError{StatusCode: 502, Message: "Bad Gateway"}},
+			{Repository: &client.Repository{FullName: "codiform/broken"}, Err: api.HTTPError{StatusCode: 403, Message: "Resource not accessible by integration"}},
+		},
+	}
+	tests := []struct {
+		name      string
+		formatter func(w io.Writer) Formatter
+		expected  string
+	}{
+		{"human", func(w io.Writer) Formatter { return humanFormatter{w} }, `codiform/gh-actions-usage (0 workflows; 0ms)
+
+Failures (2, not included above):
+- codiform/broken: HTTP 403: Resource not accessible by integration
+- codiform/flaky .github/workflows/deploy.yml: HTTP 502: Bad Gateway
+`},
+		{"tsv", func(w io.Writer) Formatter { return tsvFormatter{w} }, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS
+codiform/gh-actions-usage	n/a	0	0	0	0
+
+Repo	Workflow	Error
+codiform/broken	n/a	HTTP 403: Resource not accessible by integration
+codiform/flaky	.github/workflows/deploy.yml	HTTP 502: Bad Gateway
+`},
+		{"json", func(w io.Writer) Formatter { return jsonFormatter{w} }, `{
+  "kind": "usage",
+  "schemaVersion": 1,
+  "repositories": [
+    {"fullName": "codiform/gh-actions-usage", "owner": "codiform", "private": true, "totalMs": 0, "runners": {}, "workflows": []}
+  ],
+  "owners": [
+    {"login": "codiform", "repositoryCount": 1, "workflowCount": 0, "totalMs": 0, "runners": {}}
+  ],
+  "totals": {"repositoryCount": 1, "workflowCount": 0, "totalMs": 0, "runners": {}},
+  "failures": [
+    {"repository": "codiform/broken", "error": "HTTP 403: Resource not accessible by integration"},
+    {"repository": "codiform/flaky", "workflowId": 9, "workflowPath": ".github/workflows/deploy.yml", "error": "HTTP 502: Bad Gateway"}
+  ]
+}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			var output bytes.Buffer
+
+			// When
+			tt.formatter(&output).PrintUsage(report)
+
+			// Then
+			if tt.name == "json" {
+				assert.JSONEq(t, tt.expected, output.String())
+			} else {
+				assert.Equal(t, tt.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestFormatters_Billing(t *testing.T) {
+	codiform := &client.User{Login: "codiform", Type: "Organization"}
+	geoffreywiseman := &client.User{Login: "geoffreywiseman", Type: "User"}
+	report := Report{
+		Usage: client.RepoUsage{&client.Repository{Owner: codiform, FullName: "codiform/gh-actions-usage", Private: true}: {}},
+		Billing: map[string]*client.Billing{"codiform": {
+			TotalMinutesUsed:     1200,
+			IncludedMinutes:      2000,
+			MinutesUsedBreakdown: map[string]uint{"UBUNTU": 1000, "MACOS": 200, "WINDOWS": 0},
+		}},
+		Failures: []client.UsageError{{Owner: geoffreywiseman, Err: errBilling}},
+	}
+	tests := []struct {
+		name      string
+		formatter func(w io.Writer) Formatter
+		expected  string
+	}{
+		{"human", func(w io.Writer) Formatter { return humanFormatter{w} }, `codiform/gh-actions-usage (0 workflows; 0ms)
+
+Totals:
+- codiform (1 repositories; 0 workflows; 0ms)
+  billing period: 1200 of 2000 included minutes used; 0 paid minutes [MACOS 200, UBUNTU 1000]
+- all repositories (1 repositories; 0 workflows; 0ms)
+
+Failures (1, not included above):
+- geoffreywiseman: could not get billing
+`},
+		{"tsv", func(w io.Writer) Formatter { return tsvFormatter{w} }, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS
+codiform/gh-actions-usage	n/a	0	0	0	0
+
+Owner	Repositories	Workflows	Milliseconds	Minutes Used	Included Minutes	Paid Minutes
+codiform	1	0	0	1200	2000	0
+
+Owner	Error
+geoffreywiseman	could not get billing
+`},
+		{"json", func(w io.Writer) Formatter { return jsonFormatter{w} }, `{
+  "kind": "usage",
+  "schemaVersion": 1,
+  "repositories": [
+    {"fullName": "codiform/gh-actions-usage", "owner": "codiform", "private": true, "totalMs": 0, "runners": {}, "workflows": []}
+  ],
+  "owners": [
+    {
+      "login": "codiform", "repositoryCount": 1, "workflowCount": 0, "totalMs": 0, "runners": {},
+      "billing": {"totalMinutesUsed": 1200, "totalPaidMinutesUsed": 0, "includedMinutes": 2000, "minutesUsedBreakdown": {"MACOS": 200, "UBUNTU": 1000, "WINDOWS": 0}}
+    }
+  ],
+  "totals": {"repositoryCount": 1, "workflowCount": 0, "totalMs": 0, "runners": {}},
+  "failures": [{"owner": "geoffreywiseman", "error": "could not get billing"}]
+}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			var output bytes.Buffer
+
+			// When
+			tt.formatter(&output).PrintUsage(report)
+
+			// Then
+			if tt.name == "json" {
+				assert.JSONEq(t, tt.expected, output.String())
+			} else {
+				assert.Equal(t, tt.expected, output.String())
+			}
+		})
+	}
+}
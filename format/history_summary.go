@@ -0,0 +1,104 @@
+package format
+
+import (
+	"sort"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/history"
+)
+
+// trendPoint is the usage of a repository or workflow in one snapshot
+type trendPoint struct {
+	Time time.Time
+	// Present is false if the repository or workflow wasn't in the snapshot
+	Present bool
+	Usage   uint
+	Runners runnerUsage
+}
+
+type workflowTrend struct {
+	// Workflow is as it was in the latest snapshot that included it
+	Workflow client.Workflow
+	Points   []trendPoint
+}
+
+type repoTrend struct {
+	FullName  string
+	Points    []trendPoint
+	Workflows []workflowTrend
+}
+
+type historySummary struct {
+	Times []time.Time
+	Repos []repoTrend
+}
+
+// workflowKey identifies a workflow across snapshots; the ID doesn't change when a workflow is renamed or disabled
+type workflowKey struct {
+	repo string
+	id   uint
+	path string
+}
+
+func newWorkflowKey(repo string, workflow client.Workflow) workflowKey {
+	if workflow.ID == 0 {
+		return workflowKey{repo: repo, path: workflow.Path}
+	}
+	return workflowKey{repo: repo, id: workflow.ID}
+}
+
+// summarizeHistory lines up the usage of each repository and workflow across the snapshots, oldest first
+func summarizeHistory(snapshots []history.Snapshot) historySummary {
+	times := make([]time.Time, 0, len(snapshots))
+	repos := make(map[string]*repoTrend)
+	workflows := make(map[workflowKey]*workflowTrend)
+
+	for i, snapshot := range snapshots {
+		times = append(times, snapshot.Time)
+		summary := summarizeUsage(Report{Usage: snapshot.RepoUsage()})
+		for _, repo := range summary.Repos {
+			trend := repos[repo.Repo.FullName]
+			if trend == nil {
+				trend = &repoTrend{FullName: repo.Repo.FullName, Points: emptyPoints(snapshots)}
+				repos[repo.Repo.FullName] = trend
+			}
+			trend.Points[i] = trendPoint{Time: snapshot.Time, Present: true, Usage: repo.Total, Runners: repo.Runners}
+
+			for _, workflow := range repo.Workflows {
+				key := newWorkflowKey(repo.Repo.FullName, workflow.Workflow)
+				flowTrend := workflows[key]
+				if flowTrend == nil {
+					flowTrend = &workflowTrend{Points: emptyPoints(snapshots)}
+					workflows[key] = flowTrend
+				}
+				flowTrend.Workflow = workflow.Workflow
+				flowTrend.Points[i] = trendPoint{Time: snapshot.Time, Present: true, Usage: workflow.Usage, Runners: workflow.Runners}
+			}
+		}
+	}
+
+	for key, flowTrend := range workflows {
+		repo := repos[key.repo]
+		repo.Workflows = append(repo.Workflows, *flowTrend)
+	}
+	summary := historySummary{Times: times, Repos: make([]repoTrend, 0, len(repos))}
+	for _, repo := range repos {
+		sort.Slice(repo.Workflows, func(i, j int) bool {
+			return workflowLess(repo.Workflows[i].Workflow, repo.Workflows[j].Workflow)
+		})
+		summary.Repos = append(summary.Repos, *repo)
+	}
+	sort.Slice(summary.Repos, func(i, j int) bool {
+		return summary.Repos[i].FullName < summary.Repos[j].FullName
+	})
+	return summary
+}
+
+func emptyPoints(snapshots []history.Snapshot) []trendPoint {
+	points := make([]trendPoint, len(snapshots))
+	for i, snapshot := range snapshots {
+		points[i].Time = snapshot.Time
+	}
+	return points
+}
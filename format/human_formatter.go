@@ -3,38 +3,333 @@ package format
 import (
 	"fmt"
 	"io"
+	"sort"
+	"strings"
 
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
 	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/history"
 )
 
 type humanFormatter struct {
 	w io.Writer
 }
 
-func (hf humanFormatter) PrintUsage(usage client.RepoUsage) {
-	summary := summarizeUsage(usage)
+func (hf humanFormatter) PrintUsage(report Report) {
+	if report.Host != "" {
+		_, _ = fmt.Fprintf(hf.w, "Host: %s\n\n", report.Host)
+	}
+	if report.GroupBy != "" {
+		hf.printLeaderboard(summarizeLeaderboard(report), CheckBudgets(report))
+		return
+	}
+	summary := summarizeUsage(report)
 	for _, repo := range summary.Repos {
 		visibility := ""
 		if !repo.Private {
 			visibility = "; public"
 		}
-		if len(repo.Workflows) == 0 {
-			_, _ = fmt.Fprintf(hf.w, "%s (0 workflows; 0ms%s)\n", repo.Repo.FullName, visibility)
+		spend := humanizeCost(summary, repo.Cost) + humanizeJobTime(summary, repo.JobTime)
+		if len(repo.Workflows) == 0 && repo.Hidden.Count == 0 {
+			_, _ = fmt.Fprintf(hf.w, "%s (0 workflows; 0ms%s%s)\n", repo.Repo.FullName, spend, visibility)
 		} else {
-			_, _ = fmt.Fprintf(hf.w, "%s (%d workflows; %s%s):\n", repo.Repo.FullName, len(repo.Workflows), Humanize(repo.Total), visibility)
+			_, _ = fmt.Fprintf(hf.w, "%s (%d workflows; %s%s%s):\n", repo.Repo.FullName, len(repo.Workflows)+repo.Hidden.Count, humanizeRunners(repo.Total, repo.Runners), spend, visibility)
 			for _, workflow := range repo.Workflows {
-				_, _ = fmt.Fprintf(hf.w, "- %s (%s, %s, %s)\n", workflow.Workflow.Name, workflow.Workflow.Path, workflow.Workflow.State, Humanize(workflow.Usage))
+				_, _ = fmt.Fprintf(hf.w, "- %s (%s, %s, %s%s)\n", workflow.Workflow.Name, workflow.Workflow.Path, workflow.Workflow.State, humanizeRunners(workflow.Usage, workflow.Runners), humanizeCost(summary, workflow.Cost)+humanizeJobTime(summary, workflow.JobTime))
+				for _, run := range workflow.Runs {
+					_, _ = fmt.Fprintf(hf.w, "  - #%d (%s, %s, %s, %s; ran %s; %s%s)\n", run.Run.Number, run.Run.Event, run.Run.Branch, run.Run.Actor, runOutcome(run.Run),
+						Humanize(run.Duration), humanizeRunners(run.Usage, run.Runners), humanizeCost(summary, run.Cost))
+				}
+				hf.printTopJobs("  ", workflow.Jobs, false)
+			}
+			if repo.Hidden.Count > 0 {
+				_, _ = fmt.Fprintf(hf.w, "- %s\n", describeHidden(repo.Hidden))
+			}
+			if len(repo.Workflows) > 1 {
+				hf.printTopJobs("", repo.Jobs, true)
 			}
 		}
 		_, _ = fmt.Fprintln(hf.w)
 	}
-	if summary.RepoCount <= 1 {
+	hasTotals := summary.RepoCount > 1 || summary.HasBilling
+	if hasTotals {
+		hf.printTotals(summary)
+	}
+	budgets := CheckBudgets(report)
+	if len(budgets) > 0 {
+		if hasTotals {
+			_, _ = fmt.Fprintln(hf.w)
+		}
+		hf.printBudgets(budgets)
+	}
+	hf.printFailures(summary, hasTotals || len(budgets) > 0)
+}
+
+// printLeaderboard ranks the usage by workflow, repository or owner, with each one's share of the total
+func (hf humanFormatter) printLeaderboard(board leaderboard, budgets []budget.Status) {
+	summary := board.Summary
+	_, _ = fmt.Fprintf(hf.w, "%s by usage (%s%s):\n", capitalize(board.GroupBy.plural()), Humanize(board.Total), humanizeCost(summary, board.Cost))
+	for _, entry := range board.Entries {
+		var name string
+		switch board.GroupBy {
+		case GroupByOwner:
+			name = fmt.Sprintf("%s (%d repositories; %d workflows)", entry.Owner, entry.RepoCount, entry.WorkflowCount)
+		case GroupByRepo:
+			name = fmt.Sprintf("%s (%d workflows)", entry.Repo, entry.WorkflowCount)
+		default:
+			name = fmt.Sprintf("%s: %s (%s)", entry.Repo, entry.Workflow.Name, entry.Workflow.Path)
+		}
+		_, _ = fmt.Fprintf(hf.w, "%d. %s: %s, %.1f%%%s\n", entry.Rank, name, Humanize(entry.Usage), entry.Share, humanizeCost(summary, entry.Cost))
+	}
+	if board.Rest.Count > 0 {
+		_, _ = fmt.Fprintf(hf.w, "plus %d more %s, %s\n", board.Rest.Count, board.GroupBy.plural(), Humanize(board.Rest.Usage))
+	}
+	if len(budgets) > 0 {
+		_, _ = fmt.Fprintln(hf.w)
+		hf.printBudgets(budgets)
+	}
+	if len(summary.Failures) > 0 {
+		_, _ = fmt.Fprintln(hf.w)
+		hf.listFailures(summary.Failures)
+	}
+}
+
+// printBudgets shows the usage of each budget, and which are near or over their limit
+func (hf humanFormatter) printBudgets(budgets []budget.Status) {
+	_, _ = fmt.Fprintln(hf.w, "Budgets:")
+	for _, status := range budgets {
+		state := ""
+		switch status.State {
+		case budget.StateNear:
+			state = "; near limit"
+		case budget.StateExceeded:
+			state = "; over budget"
+		}
+		if status.Unmatched {
+			state += "; not in the report"
+		}
+		_, _ = fmt.Fprintf(hf.w, "- %s: %s of %s (%.1f%%%s)\n", budgetName(status), status.Limit.Format(status.Used), status.Limit.Format(status.Limit.Amount), status.Percent(), state)
+	}
+}
+
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// topJobs is the number of jobs shown for each workflow and repository
+const topJobs = 5
+
+// printTopJobs lists the jobs that used the most time, if any were collected, optionally including their workflow
+func (hf humanFormatter) printTopJobs(indent string, jobs []jobSummary, withWorkflow bool) {
+	if len(jobs) == 0 {
 		return
 	}
+	_, _ = fmt.Fprintf(hf.w, "%stop jobs:\n", indent)
+	for i, job := range jobs {
+		if i == topJobs {
+			break
+		}
+		workflow := ""
+		if withWorkflow {
+			workflow = job.Workflow + ": "
+		}
+		_, _ = fmt.Fprintf(hf.w, "%s  - %s%s: %s in %d runs\n", indent, workflow, humanizeJob(job), Humanize(job.Usage), job.Count)
+	}
+}
 
+func (hf humanFormatter) printTotals(summary usageSummary) {
 	_, _ = fmt.Fprintln(hf.w, "Totals:")
 	for _, owner := range summary.Owners {
-		_, _ = fmt.Fprintf(hf.w, "- %s (%d repositories; %d workflows; %s)\n", owner.Owner, owner.RepoCount, owner.WorkflowCount, Humanize(owner.Total))
+		_, _ = fmt.Fprintf(hf.w, "- %s (%d repositories; %d workflows; %s%s)\n", owner.Owner, owner.RepoCount, owner.WorkflowCount, humanizeRunners(owner.Total, owner.Runners), humanizeCost(summary, owner.Cost)+humanizeJobTime(summary, owner.JobTime)+humanizeHidden(owner.Hidden))
+		if owner.Billing != nil {
+			_, _ = fmt.Fprintf(hf.w, "  billing period: %s\n", humanizeBilling(owner.Billing))
+		}
+	}
+	_, _ = fmt.Fprintf(hf.w, "- all repositories (%d repositories; %d workflows; %s%s)\n", summary.RepoCount, summary.WorkflowCount, humanizeRunners(summary.Total, summary.Runners), humanizeCost(summary, summary.Cost)+humanizeJobTime(summary, summary.JobTime)+humanizeHidden(summary.Hidden))
+}
+
+// printFailures lists the repositories and workflows that are missing from the report, since the totals don't include them
+func (hf humanFormatter) printFailures(summary usageSummary, separate bool) {
+	if len(summary.Failures) == 0 {
+		return
+	}
+	if separate {
+		_, _ = fmt.Fprintln(hf.w)
+	}
+	hf.listFailures(summary.Failures)
+}
+
+func (hf humanFormatter) listFailures(failures []client.UsageError) {
+	_, _ = fmt.Fprintf(hf.w, "Failures (%d, not included above):\n", len(failures))
+	for _, failure := range failures {
+		_, _ = fmt.Fprintf(hf.w, "- %s: %s\n", failure.Subject(), failure.Reason())
+	}
+}
+
+// hostedRunnerGroup is the runner group of GitHub-hosted runners, which isn't worth showing
+const hostedRunnerGroup = "GitHub Actions"
+
+// historyTimeLayout is how snapshot times are shown in human output, in UTC
+const historyTimeLayout = "2006-01-02 15:04"
+
+func (hf humanFormatter) PrintHistory(snapshots []history.Snapshot) {
+	if len(snapshots) == 0 {
+		_, _ = fmt.Fprintln(hf.w, "No snapshots found; use --snapshot to store the usage for each run.")
+		return
+	}
+	summary := summarizeHistory(snapshots)
+	times := make([]string, 0, len(summary.Times))
+	for _, t := range summary.Times {
+		times = append(times, t.UTC().Format(historyTimeLayout))
+	}
+	_, _ = fmt.Fprintf(hf.w, "Snapshots (UTC): %s\n\n", strings.Join(times, ", "))
+
+	for _, repo := range summary.Repos {
+		_, _ = fmt.Fprintf(hf.w, "%s: %s\n", repo.FullName, humanizeTrend(repo.Points))
+		for _, workflow := range repo.Workflows {
+			_, _ = fmt.Fprintf(hf.w, "- %s (%s): %s\n", workflow.Workflow.Name, workflow.Workflow.Path, humanizeTrend(workflow.Points))
+		}
+		_, _ = fmt.Fprintln(hf.w)
+	}
+}
+
+// humanizeTrend shows the usage in each snapshot, e.g. "1h 2m → 2h 10m → -", where - means it wasn't in the snapshot
+func humanizeTrend(points []trendPoint) string {
+	values := make([]string, 0, len(points))
+	for _, point := range points {
+		if point.Present {
+			values = append(values, Humanize(point.Usage))
+		} else {
+			values = append(values, "-")
+		}
+	}
+	return strings.Join(values, " → ")
+}
+
+// humanizeJob describes a job and the runner it used, e.g. "test (macos) [macos-14]" or "build [self-hosted, linux; group Fleet]"
+func humanizeJob(job jobSummary) string {
+	runner := strings.Join(job.Labels, ", ")
+	if job.RunnerGroup != "" && job.RunnerGroup != hostedRunnerGroup {
+		runner += "; group " + job.RunnerGroup
+	}
+	if runner == "" {
+		return job.Name
+	}
+	return fmt.Sprintf("%s [%s]", job.Name, runner)
+}
+
+// runOutcome is the conclusion of a run, or its status if it hasn't finished
+func runOutcome(run client.Run) string {
+	if run.Conclusion != "" {
+		return run.Conclusion
+	}
+	return run.Status
+}
+
+// humanizeRunners formats total usage followed by subtotals for each runner environment that was used,
+// e.g. "2m 30s [MACOS 2m 0s, UBUNTU 30s 0ms]"
+func humanizeRunners(total uint, runners runnerUsage) string {
+	envs := runners.environments()
+	if len(envs) == 0 {
+		return Humanize(total)
+	}
+	subtotals := make([]string, 0, len(envs))
+	for _, env := range envs {
+		subtotals = append(subtotals, env+" "+Humanize(runners[env]))
+	}
+	return fmt.Sprintf("%s [%s]", Humanize(total), strings.Join(subtotals, ", "))
+}
+
+// humanizeCost formats an estimated cost as a suffix for the usage, or nothing if the summary has no cost estimates
+func humanizeCost(summary usageSummary, cost float64) string {
+	if !summary.HasCost {
+		return ""
+	}
+	return "; est. " + formatCost(cost)
+}
+
+// humanizeJobTime formats how long jobs ran on self-hosted and GitHub-hosted runners as a suffix for the usage, or
+// nothing if it wasn't requested
+func humanizeJobTime(summary usageSummary, time jobTime) string {
+	if !summary.HasSelfHosted {
+		return ""
+	}
+	return "; self-hosted " + Humanize(time.SelfHosted) + "; GitHub-hosted " + Humanize(time.Hosted)
+}
+
+// humanizeHidden formats the workflows hidden by the report's filter as a suffix for a total, or nothing if none were hidden
+func humanizeHidden(hidden hiddenWorkflows) string {
+	if hidden.Count == 0 {
+		return ""
+	}
+	return "; " + describeHidden(hidden)
+}
+
+// describeHidden describes the workflows hidden by the report's filter, e.g. "plus 12 hidden workflows, 3m 0s"
+func describeHidden(hidden hiddenWorkflows) string {
+	noun := "workflows"
+	if hidden.Count == 1 {
+		noun = "workflow"
+	}
+	return fmt.Sprintf("plus %d hidden %s, %s", hidden.Count, noun, Humanize(hidden.Usage))
+}
+
+// humanizeBilling describes an owner's billing summary, e.g. "1200 of 2000 included minutes used; 0 paid minutes [UBUNTU 1200]"
+func humanizeBilling(billing *client.Billing) string {
+	text := fmt.Sprintf("%d of %d included minutes used; %.0f paid minutes", billing.TotalMinutesUsed, billing.IncludedMinutes, billing.TotalPaidMinutesUsed)
+	envs := make([]string, 0, len(billing.MinutesUsedBreakdown))
+	for env, minutes := range billing.MinutesUsedBreakdown {
+		if minutes > 0 {
+			envs = append(envs, env)
+		}
+	}
+	if len(envs) == 0 {
+		return text
+	}
+	sort.Strings(envs)
+	breakdown := make([]string, 0, len(envs))
+	for _, env := range envs {
+		breakdown = append(breakdown, fmt.Sprintf("%s %d", env, billing.MinutesUsedBreakdown[env]))
+	}
+	return fmt.Sprintf("%s [%s]", text, strings.Join(breakdown, ", "))
+}
+
+func (hf humanFormatter) PrintDiff(diff Diff) {
+	summary := summarizeDiff(diff)
+	_, _ = fmt.Fprintf(hf.w, "Comparing %s → %s\n\n", diff.BeforeName, diff.AfterName)
+	for _, repo := range summary.Repos {
+		_, _ = fmt.Fprintf(hf.w, "%s: %s\n", repo.FullName, humanizeChange(repo.usageChange))
+		for _, workflow := range repo.Workflows {
+			_, _ = fmt.Fprintf(hf.w, "- %s (%s): %s\n", workflow.Workflow.Name, workflow.Workflow.Path, humanizeChange(workflow.usageChange))
+		}
+		_, _ = fmt.Fprintln(hf.w)
+	}
+	_, _ = fmt.Fprintf(hf.w, "Total: %s\n", humanizeChange(summary.Total))
+}
+
+// humanizeChange describes a change in usage, e.g. "1m 0s → 1m 30s (+30s 0ms, +50.0%)" or "new, 30s 0ms"
+func humanizeChange(change usageChange) string {
+	switch change.Status {
+	case statusNew:
+		return "new, " + Humanize(change.After)
+	case statusRemoved:
+		return "removed, was " + Humanize(change.Before)
+	case statusUnchanged:
+		return fmt.Sprintf("%s (no change)", Humanize(change.After))
+	}
+	sign := "+"
+	delta := change.After - change.Before
+	if change.After < change.Before {
+		sign = "-"
+		delta = change.Before - change.After
+	}
+	text := fmt.Sprintf("%s → %s (%s%s", Humanize(change.Before), Humanize(change.After), sign, Humanize(delta))
+	if pct, ok := change.Percent(); ok {
+		text += fmt.Sprintf(", %+.1f%%", pct)
 	}
-	_, _ = fmt.Fprintf(hf.w, "- all repositories (%d repositories; %d workflows; %s)\n", summary.RepoCount, summary.WorkflowCount, Humanize(summary.Total))
+	return text + ")"
 }
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
 	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/cost"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHumanFormatter(t *testing.T) {
@@ -15,17 +18,36 @@ func TestHumanFormatter(t *testing.T) {
 
 	wf := client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}
 	wfu := make(client.WorkflowUsage)
-	wfu[wf] = 50
+	wfu[wf] = billable(map[string]uint{"UBUNTU": 50})
 	r := client.Repository{FullName: "codiform/gh-actions-usage", Private: true}
 	ru := make(client.RepoUsage)
 	ru[&r] = wfu
 
 	// When
-	formatter.PrintUsage(ru)
+	formatter.PrintUsage(Report{Usage: ru})
 
 	// Then
-	assert.Equal(t, `codiform/gh-actions-usage (1 workflows; 50ms):
-- CI (.github/workflows/ci.yml, active, 50ms)
+	assert.Equal(t, `codiform/gh-actions-usage (1 workflows; 50ms [UBUNTU 50ms]):
+- CI (.github/workflows/ci.yml, active, 50ms [UBUNTU 50ms])
+
+`, output.String())
+}
+
+func TestHumanFormatter_Host(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+
+	r := client.Repository{FullName: "platform/deployer", Private: true}
+	ru := client.RepoUsage{&r: make(client.WorkflowUsage)}
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, Host: "github.example.com"})
+
+	// Then
+	assert.Equal(t, `Host: github.example.com
+
+platform/deployer (0 workflows; 0ms)
 
 `, output.String())
 }
@@ -40,7 +62,7 @@ func TestHumanFormatter_Empty(t *testing.T) {
 	ru[&r] = make(client.WorkflowUsage)
 
 	// When
-	formatter.PrintUsage(ru)
+	formatter.PrintUsage(Report{Usage: ru})
 
 	// Then
 	assert.Equal(t, `geoffreywiseman/Moo (0 workflows; 0ms)
@@ -55,13 +77,13 @@ func TestHumanFormatter_PublicRepo(t *testing.T) {
 
 	wf := client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}
 	wfu := make(client.WorkflowUsage)
-	wfu[wf] = 0
+	wfu[wf] = billable(map[string]uint{"UBUNTU": 0})
 	r := client.Repository{FullName: "geoffreywiseman/gh-actuse"}
 	ru := make(client.RepoUsage)
 	ru[&r] = wfu
 
 	// When
-	formatter.PrintUsage(ru)
+	formatter.PrintUsage(Report{Usage: ru})
 
 	// Then
 	assert.Equal(t, `geoffreywiseman/gh-actuse (1 workflows; 0ms; public):
@@ -80,7 +102,7 @@ func TestHumanFormatter_PublicRepo_NoWorkflows(t *testing.T) {
 	ru[&r] = make(client.WorkflowUsage)
 
 	// When
-	formatter.PrintUsage(ru)
+	formatter.PrintUsage(Report{Usage: ru})
 
 	// Then
 	assert.Equal(t, `geoffreywiseman/public-empty (0 workflows; 0ms; public)
@@ -95,21 +117,275 @@ func TestHumanFormatter_Totals(t *testing.T) {
 	ru := sampleMultipleRepositoriesUsage()
 
 	// When
-	formatter.PrintUsage(ru)
+	formatter.PrintUsage(Report{Usage: ru})
+
+	// Then
+	assert.Equal(t, `codiform/gh-actions-usage (2 workflows; 2s 0ms [MACOS 500ms, UBUNTU 1s 500ms]):
+- CI (.github/workflows/ci.yml, active, 500ms [UBUNTU 500ms])
+- Release (.github/workflows/release.yml, active, 1s 500ms [MACOS 500ms, UBUNTU 1s 0ms])
+
+codiform/terraform-tools (1 workflows; 1s 0ms [WINDOWS 1s 0ms]):
+- CI (.github/workflows/ci.yml, active, 1s 0ms [WINDOWS 1s 0ms])
+
+geoffreywiseman/gh-actuse (0 workflows; 0ms; public)
+
+Totals:
+- codiform (2 repositories; 3 workflows; 3s 0ms [MACOS 500ms, UBUNTU 1s 500ms, WINDOWS 1s 0ms])
+- geoffreywiseman (1 repositories; 0 workflows; 0ms)
+- all repositories (3 repositories; 3 workflows; 3s 0ms [MACOS 500ms, UBUNTU 1s 500ms, WINDOWS 1s 0ms])
+`, output.String())
+}
+
+func TestHumanFormatter_Cost(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, Cost: cost.Default()})
+
+	// Then
+	assert.Equal(t, `codiform/gh-actions-usage (2 workflows; 2s 0ms [MACOS 500ms, UBUNTU 1s 500ms]; est. $0.10):
+- CI (.github/workflows/ci.yml, active, 500ms [UBUNTU 500ms]; est. $0.01)
+- Release (.github/workflows/release.yml, active, 1s 500ms [MACOS 500ms, UBUNTU 1s 0ms]; est. $0.09)
+
+codiform/terraform-tools (1 workflows; 1s 0ms [WINDOWS 1s 0ms]; est. $0.02):
+- CI (.github/workflows/ci.yml, active, 1s 0ms [WINDOWS 1s 0ms]; est. $0.02)
+
+geoffreywiseman/gh-actuse (0 workflows; 0ms; est. $0.00; public)
+
+Totals:
+- codiform (2 repositories; 3 workflows; 3s 0ms [MACOS 500ms, UBUNTU 1s 500ms, WINDOWS 1s 0ms]; est. $0.11)
+- geoffreywiseman (1 repositories; 0 workflows; 0ms; est. $0.00)
+- all repositories (3 repositories; 3 workflows; 3s 0ms [MACOS 500ms, UBUNTU 1s 500ms, WINDOWS 1s 0ms]; est. $0.11)
+`, output.String())
+}
+
+func TestHumanFormatter_History(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+
+	// When
+	formatter.PrintHistory(sampleHistory())
+
+	// Then
+	assert.Equal(t, `Snapshots (UTC): 2026-09-01 12:00, 2026-10-01 12:00
+
+codiform/gh-actions-usage: 1m 0s → 2m 0s
+- CI (.github/workflows/ci.yml): 1m 0s → 1m 30s
+- Release (.github/workflows/release.yml): - → 30s 0ms
+
+`, output.String())
+}
+
+func TestHumanFormatter_History_Empty(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+
+	// When
+	formatter.PrintHistory(nil)
+
+	// Then
+	assert.Equal(t, "No snapshots found; use --snapshot to store the usage for each run.\n", output.String())
+}
+
+func TestHumanFormatter_Diff(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+
+	// When
+	formatter.PrintDiff(sampleDiff())
+
+	// Then
+	assert.Equal(t, `Comparing before.json → after.json
+
+codiform/gh-actions-usage: 1m 10s → 2m 0s (+50s 0ms, +71.4%)
+- CI (.github/workflows/ci.yml): 1m 0s → 1m 30s (+30s 0ms, +50.0%)
+- Old (.github/workflows/old.yml): removed, was 10s 0ms
+- Release (.github/workflows/release.yml): new, 30s 0ms
+
+codiform/terraform-tools: removed, was 1s 0ms
+- CI (.github/workflows/ci.yml): removed, was 1s 0ms
+
+Total: 1m 11s → 2m 0s (+49s 0ms, +69.0%)
+`, output.String())
+}
+
+func TestHumanFormatter_Runs(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+
+	// When
+	formatter.PrintUsage(Report{Usage: sampleRunsUsage(), Runs: true})
+
+	// Then
+	assert.Equal(t, `codiform/gh-actions-usage (2 workflows; 2m 30s [UBUNTU 2m 30s]):
+- CI (.github/workflows/ci.yml, active, 2m 30s [UBUNTU 2m 30s])
+  - #1 (push, main, geoffreywiseman, success; ran 1m 30s; 2m 0s [UBUNTU 2m 0s])
+  - #2 (pull_request, feature, octocat, in_progress; ran 30s 0ms; 30s 0ms [UBUNTU 30s 0ms])
+  top jobs:
+    - build [ubuntu-latest]: 2m 10s in 2 runs
+    - lint [ubuntu-latest]: 20s 0ms in 1 runs
+- Deploy (.github/workflows/deploy.yml, active, 0ms)
+  - #1 (push, main, octocat, success; ran 5m 0s; 0ms)
+  top jobs:
+    - deploy [self-hosted, linux; group Fleet]: 5m 0s in 1 runs
+top jobs:
+  - .github/workflows/deploy.yml: deploy [self-hosted, linux; group Fleet]: 5m 0s in 1 runs
+  - .github/workflows/ci.yml: build [ubuntu-latest]: 2m 10s in 2 runs
+  - .github/workflows/ci.yml: lint [ubuntu-latest]: 20s 0ms in 1 runs
+
+`, output.String())
+}
+
+func TestHumanFormatter_Jobs(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+
+	// When
+	formatter.PrintUsage(Report{Usage: sampleRunsUsage()})
+
+	// Then
+	assert.Equal(t, `codiform/gh-actions-usage (2 workflows; 2m 30s [UBUNTU 2m 30s]):
+- CI (.github/workflows/ci.yml, active, 2m 30s [UBUNTU 2m 30s])
+  top jobs:
+    - build [ubuntu-latest]: 2m 10s in 2 runs
+    - lint [ubuntu-latest]: 20s 0ms in 1 runs
+- Deploy (.github/workflows/deploy.yml, active, 0ms)
+  top jobs:
+    - deploy [self-hosted, linux; group Fleet]: 5m 0s in 1 runs
+top jobs:
+  - .github/workflows/deploy.yml: deploy [self-hosted, linux; group Fleet]: 5m 0s in 1 runs
+  - .github/workflows/ci.yml: build [ubuntu-latest]: 2m 10s in 2 runs
+  - .github/workflows/ci.yml: lint [ubuntu-latest]: 20s 0ms in 1 runs
+
+`, output.String())
+}
+
+func TestHumanFormatter_SelfHosted(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+	summary := summarizeUsage(Report{Usage: sampleRunsUsage(), SelfHosted: true})
+
+	// When
+	formatter.printTotals(summary)
+
+	// Then
+	assert.Equal(t, `Totals:
+- codiform (1 repositories; 2 workflows; 2m 30s [UBUNTU 2m 30s]; self-hosted 5m 0s; GitHub-hosted 2m 21s)
+- all repositories (1 repositories; 2 workflows; 2m 30s [UBUNTU 2m 30s]; self-hosted 5m 0s; GitHub-hosted 2m 21s)
+`, output.String())
+	assert.Equal(t, jobTime{Hosted: 141000}, summary.Repos[0].Workflows[0].JobTime)
+	assert.Equal(t, jobTime{SelfHosted: 300000}, summary.Repos[0].Workflows[1].JobTime)
+}
+
+func TestHumanFormatter_HiddenWorkflows(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, Workflows: WorkflowFilter{MinUsage: 1000}})
 
 	// Then
-	assert.Equal(t, `codiform/gh-actions-usage (2 workflows; 2s 0ms):
-- CI (.github/workflows/ci.yml, active, 500ms)
-- Release (.github/workflows/release.yml, active, 1s 500ms)
+	assert.Equal(t, `codiform/gh-actions-usage (2 workflows; 2s 0ms [MACOS 500ms, UBUNTU 1s 500ms]):
+- Release (.github/workflows/release.yml, active, 1s 500ms [MACOS 500ms, UBUNTU 1s 0ms])
+- plus 1 hidden workflow, 500ms
 
-codiform/terraform-tools (1 workflows; 1s 0ms):
-- CI (.github/workflows/ci.yml, active, 1s 0ms)
+codiform/terraform-tools (1 workflows; 1s 0ms [WINDOWS 1s 0ms]):
+- CI (.github/workflows/ci.yml, active, 1s 0ms [WINDOWS 1s 0ms])
 
 geoffreywiseman/gh-actuse (0 workflows; 0ms; public)
 
 Totals:
-- codiform (2 repositories; 3 workflows; 3s 0ms)
+- codiform (2 repositories; 3 workflows; 3s 0ms [MACOS 500ms, UBUNTU 1s 500ms, WINDOWS 1s 0ms]; plus 1 hidden workflow, 500ms)
 - geoffreywiseman (1 repositories; 0 workflows; 0ms)
-- all repositories (3 repositories; 3 workflows; 3s 0ms)
+- all repositories (3 repositories; 3 workflows; 3s 0ms [MACOS 500ms, UBUNTU 1s 500ms, WINDOWS 1s 0ms]; plus 1 hidden workflow, 500ms)
+`, output.String())
+}
+
+func TestHumanFormatter_Top(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, Order: Order{Key: SortByUsage, Descending: true, Top: 2}})
+
+	// Then
+	assert.Equal(t, `codiform/gh-actions-usage (2 workflows; 2s 0ms [MACOS 500ms, UBUNTU 1s 500ms]):
+- Release (.github/workflows/release.yml, active, 1s 500ms [MACOS 500ms, UBUNTU 1s 0ms])
+- plus 1 hidden workflow, 500ms
+
+codiform/terraform-tools (1 workflows; 1s 0ms [WINDOWS 1s 0ms]):
+- CI (.github/workflows/ci.yml, active, 1s 0ms [WINDOWS 1s 0ms])
+
+Totals:
+- codiform (2 repositories; 3 workflows; 3s 0ms [MACOS 500ms, UBUNTU 1s 500ms, WINDOWS 1s 0ms]; plus 1 hidden workflow, 500ms)
+- geoffreywiseman (1 repositories; 0 workflows; 0ms)
+- all repositories (3 repositories; 3 workflows; 3s 0ms [MACOS 500ms, UBUNTU 1s 500ms, WINDOWS 1s 0ms]; plus 1 hidden workflow, 500ms)
+`, output.String())
+}
+
+func TestHumanFormatter_GroupByWorkflow(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, GroupBy: GroupByWorkflow})
+
+	// Then
+	assert.Equal(t, `Workflows by usage (3s 0ms):
+1. codiform/gh-actions-usage: Release (.github/workflows/release.yml): 1s 500ms, 50.0%
+2. codiform/terraform-tools: CI (.github/workflows/ci.yml): 1s 0ms, 33.3%
+3. codiform/gh-actions-usage: CI (.github/workflows/ci.yml): 500ms, 16.7%
+`, output.String())
+}
+
+func TestHumanFormatter_GroupByRepo(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, GroupBy: GroupByRepo, Order: Order{Top: 2}, Cost: cost.Default()})
+
+	// Then
+	assert.Equal(t, `Repositories by usage (3s 0ms; est. $0.11):
+1. codiform/gh-actions-usage (2 workflows): 2s 0ms, 66.7%; est. $0.10
+2. codiform/terraform-tools (1 workflows): 1s 0ms, 33.3%; est. $0.02
+plus 1 more repositories, 0ms
+`, output.String())
+}
+
+func TestHumanFormatter_Budgets(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := humanFormatter{&output}
+	budgets := budget.New()
+	require.NoError(t, budgets.Set("$1"))
+	require.NoError(t, budgets.Set("codiform=$0.10"))
+	require.NoError(t, budgets.Set("codiform/terraform-tools=$0.02"))
+
+	// When
+	formatter.printBudgets(CheckBudgets(Report{Usage: sampleMultipleRepositoriesUsage(), Budgets: budgets}))
+
+	// Then
+	assert.Equal(t, `Budgets:
+- all repositories: $0.11 of $1.00 (11.2%)
+- codiform: $0.11 of $0.10 (112.0%; over budget)
+- codiform/terraform-tools: $0.02 of $0.02 (80.0%; near limit)
 `, output.String())
 }
@@ -19,3 +19,8 @@ func Humanize(ms uint) string {
 		return fmt.Sprintf("%dh %dm", ms/msInH, (ms%msInH)/msInM)
 	}
 }
+
+// formatCost returns an estimated cost in dollars, rounded to the cent
+func formatCost(cost float64) string {
+	return fmt.Sprintf("$%.2f", cost)
+}
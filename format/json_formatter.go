@@ -0,0 +1,547 @@
+package format
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/history"
+)
+
+// jsonSchemaVersion is the version of the JSON output's schema. It changes when fields are removed or their
+// meaning changes; new fields can be added without changing the version.
+const jsonSchemaVersion = 1
+
+// The kinds of JSON document, so that readers can tell a usage report from the other documents, which share
+// its schema version
+const (
+	jsonUsageKind       = "usage"
+	jsonLeaderboardKind = "leaderboard"
+	jsonHistoryKind     = "history"
+	jsonDiffKind        = "diff"
+)
+
+type jsonFormatter struct {
+	w io.Writer
+}
+
+type jsonReport struct {
+	Kind          string           `json:"kind"`
+	SchemaVersion int              `json:"schemaVersion"`
+	Host          string           `json:"host,omitempty"`
+	Repositories  []jsonRepository `json:"repositories"`
+	Owners        []jsonOwner      `json:"owners"`
+	Totals        jsonTotals       `json:"totals"`
+	Budgets       []jsonBudget     `json:"budgets,omitempty"`
+	Failures      []jsonFailure    `json:"failures,omitempty"`
+}
+
+type jsonBudget struct {
+	Scope   budget.Scope `json:"scope"`
+	Name    string       `json:"name,omitempty"`
+	Unit    budget.Unit  `json:"unit"`
+	Limit   float64      `json:"limit"`
+	Used    float64      `json:"used"`
+	Percent float64      `json:"percent"`
+	State   budget.State `json:"state"`
+	// Unmatched is set when the budget's owner or repository isn't in the report
+	Unmatched bool `json:"unmatched,omitempty"`
+}
+
+type jsonFailure struct {
+	Owner        string `json:"owner,omitempty"`
+	Repository   string `json:"repository,omitempty"`
+	WorkflowID   uint   `json:"workflowId,omitempty"`
+	WorkflowPath string `json:"workflowPath,omitempty"`
+	Error        string `json:"error"`
+}
+
+type jsonRepository struct {
+	FullName       string         `json:"fullName"`
+	Owner          string         `json:"owner"`
+	Private        bool           `json:"private"`
+	TotalMs        uint           `json:"totalMs"`
+	Runners        runnerUsage    `json:"runners"`
+	Cost           *float64       `json:"cost,omitempty"`
+	SelfHostedMs   *uint          `json:"selfHostedMs,omitempty"`
+	GitHubHostedMs *uint          `json:"githubHostedMs,omitempty"`
+	Hidden         *jsonHidden    `json:"hidden,omitempty"`
+	Workflows      []jsonWorkflow `json:"workflows"`
+	// Omitted is set for repositories left out by the report's top, which are included so that the report still
+	// has all of the usage (e.g. for diff)
+	Omitted bool `json:"omitted,omitempty"`
+}
+
+// jsonHidden counts the workflows left out by the report's filter, which are still included in the totals
+type jsonHidden struct {
+	WorkflowCount int  `json:"workflowCount"`
+	TotalMs       uint `json:"totalMs"`
+}
+
+type jsonWorkflow struct {
+	ID             uint        `json:"id"`
+	Name           string      `json:"name"`
+	Path           string      `json:"path"`
+	State          string      `json:"state"`
+	TotalMs        uint        `json:"totalMs"`
+	Runners        runnerUsage `json:"runners"`
+	Cost           *float64    `json:"cost,omitempty"`
+	SelfHostedMs   *uint       `json:"selfHostedMs,omitempty"`
+	GitHubHostedMs *uint       `json:"githubHostedMs,omitempty"`
+	Runs           []jsonRun   `json:"runs,omitempty"`
+	Jobs           []jsonJob   `json:"jobs,omitempty"`
+	// Hidden is set for workflows left out by the report's filter or top, which are included so that the report
+	// still has all of the usage (e.g. for diff)
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+type jsonJob struct {
+	Name        string   `json:"name"`
+	Labels      []string `json:"labels"`
+	RunnerGroup string   `json:"runnerGroup,omitempty"`
+	Runs        int      `json:"runs"`
+	TotalMs     uint     `json:"totalMs"`
+}
+
+type jsonRun struct {
+	ID         uint        `json:"id"`
+	Number     uint        `json:"number"`
+	Event      string      `json:"event"`
+	Branch     string      `json:"branch"`
+	Actor      string      `json:"actor"`
+	Status     string      `json:"status"`
+	Conclusion string      `json:"conclusion"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	DurationMs uint        `json:"durationMs"`
+	TotalMs    uint        `json:"totalMs"`
+	Runners    runnerUsage `json:"runners"`
+	Cost       *float64    `json:"cost,omitempty"`
+}
+
+type jsonOwner struct {
+	Login           string       `json:"login"`
+	RepositoryCount int          `json:"repositoryCount"`
+	WorkflowCount   int          `json:"workflowCount"`
+	TotalMs         uint         `json:"totalMs"`
+	Runners         runnerUsage  `json:"runners"`
+	Cost            *float64     `json:"cost,omitempty"`
+	SelfHostedMs    *uint        `json:"selfHostedMs,omitempty"`
+	GitHubHostedMs  *uint        `json:"githubHostedMs,omitempty"`
+	Hidden          *jsonHidden  `json:"hidden,omitempty"`
+	Billing         *jsonBilling `json:"billing,omitempty"`
+}
+
+type jsonBilling struct {
+	TotalMinutesUsed     uint            `json:"totalMinutesUsed"`
+	TotalPaidMinutesUsed float64         `json:"totalPaidMinutesUsed"`
+	IncludedMinutes      uint            `json:"includedMinutes"`
+	MinutesUsedBreakdown map[string]uint `json:"minutesUsedBreakdown"`
+}
+
+type jsonTotals struct {
+	RepositoryCount int         `json:"repositoryCount"`
+	WorkflowCount   int         `json:"workflowCount"`
+	TotalMs         uint        `json:"totalMs"`
+	Runners         runnerUsage `json:"runners"`
+	Cost            *float64    `json:"cost,omitempty"`
+	SelfHostedMs    *uint       `json:"selfHostedMs,omitempty"`
+	GitHubHostedMs  *uint       `json:"githubHostedMs,omitempty"`
+	Hidden          *jsonHidden `json:"hidden,omitempty"`
+}
+
+func (jf jsonFormatter) PrintUsage(report Report) {
+	if report.GroupBy != "" {
+		jf.printLeaderboard(report.Host, summarizeLeaderboard(report), CheckBudgets(report))
+		return
+	}
+	summary := summarizeUsage(report)
+	doc := jsonReport{
+		Kind:          jsonUsageKind,
+		SchemaVersion: jsonSchemaVersion,
+		Host:          report.Host,
+		Repositories:  make([]jsonRepository, 0, len(summary.Repos)),
+		Owners:        make([]jsonOwner, 0, len(summary.Owners)),
+		Totals: jsonTotals{
+			RepositoryCount: summary.RepoCount,
+			WorkflowCount:   summary.WorkflowCount,
+			TotalMs:         summary.Total,
+			Runners:         summary.Runners,
+			Cost:            jsonCost(summary, summary.Cost),
+			SelfHostedMs:    jsonJobMs(summary, summary.JobTime.SelfHosted),
+			GitHubHostedMs:  jsonJobMs(summary, summary.JobTime.Hosted),
+			Hidden:          jsonHiddenWorkflows(summary.Hidden),
+		},
+	}
+	for _, repo := range summary.Repos {
+		doc.Repositories = append(doc.Repositories, newJSONRepository(summary, repo, false))
+	}
+	for _, repo := range summary.OmittedRepos {
+		doc.Repositories = append(doc.Repositories, newJSONRepository(summary, repo, true))
+	}
+	for _, owner := range summary.Owners {
+		item := jsonOwner{
+			Login:           owner.Owner,
+			RepositoryCount: owner.RepoCount,
+			WorkflowCount:   owner.WorkflowCount,
+			TotalMs:         owner.Total,
+			Runners:         owner.Runners,
+			Cost:            jsonCost(summary, owner.Cost),
+			SelfHostedMs:    jsonJobMs(summary, owner.JobTime.SelfHosted),
+			GitHubHostedMs:  jsonJobMs(summary, owner.JobTime.Hosted),
+			Hidden:          jsonHiddenWorkflows(owner.Hidden),
+		}
+		if owner.Billing != nil {
+			item.Billing = &jsonBilling{
+				TotalMinutesUsed:     owner.Billing.TotalMinutesUsed,
+				TotalPaidMinutesUsed: owner.Billing.TotalPaidMinutesUsed,
+				IncludedMinutes:      owner.Billing.IncludedMinutes,
+				MinutesUsedBreakdown: owner.Billing.MinutesUsedBreakdown,
+			}
+		}
+		doc.Owners = append(doc.Owners, item)
+	}
+	doc.Budgets = jsonBudgets(CheckBudgets(report))
+	doc.Failures = jsonFailures(summary.Failures)
+
+	jf.encode(doc)
+}
+
+func newJSONRepository(summary usageSummary, repo repoSummary, omitted bool) jsonRepository {
+	workflows := make([]jsonWorkflow, 0, len(repo.Workflows)+len(repo.HiddenWorkflows))
+	for _, workflow := range repo.Workflows {
+		workflows = append(workflows, newJSONWorkflow(summary, workflow, false))
+	}
+	for _, workflow := range repo.HiddenWorkflows {
+		workflows = append(workflows, newJSONWorkflow(summary, workflow, true))
+	}
+	return jsonRepository{
+		FullName:       repo.Repo.FullName,
+		Owner:          repo.Owner,
+		Private:        repo.Private,
+		TotalMs:        repo.Total,
+		Runners:        repo.Runners,
+		Cost:           jsonCost(summary, repo.Cost),
+		SelfHostedMs:   jsonJobMs(summary, repo.JobTime.SelfHosted),
+		GitHubHostedMs: jsonJobMs(summary, repo.JobTime.Hosted),
+		Hidden:         jsonHiddenWorkflows(repo.Hidden),
+		Workflows:      workflows,
+		Omitted:        omitted,
+	}
+}
+
+func newJSONWorkflow(summary usageSummary, workflow workflowSummary, hidden bool) jsonWorkflow {
+	return jsonWorkflow{
+		ID:             workflow.Workflow.ID,
+		Name:           workflow.Workflow.Name,
+		Path:           workflow.Workflow.Path,
+		State:          workflow.Workflow.State,
+		TotalMs:        workflow.Usage,
+		Runners:        workflow.Runners,
+		Cost:           jsonCost(summary, workflow.Cost),
+		SelfHostedMs:   jsonJobMs(summary, workflow.JobTime.SelfHosted),
+		GitHubHostedMs: jsonJobMs(summary, workflow.JobTime.Hosted),
+		Runs:           jsonRuns(summary, workflow.Runs),
+		Jobs:           jsonJobs(workflow.Jobs),
+		Hidden:         hidden,
+	}
+}
+
+// jsonBudgets lists the usage of each budget, or nil if there aren't any
+func jsonBudgets(budgets []budget.Status) []jsonBudget {
+	var items []jsonBudget
+	for _, status := range budgets {
+		items = append(items, jsonBudget{
+			Scope:     status.Scope,
+			Name:      status.Name,
+			Unit:      status.Limit.Unit,
+			Limit:     status.Limit.Amount,
+			Used:      status.Used,
+			Percent:   status.Percent(),
+			State:     status.State,
+			Unmatched: status.Unmatched,
+		})
+	}
+	return items
+}
+
+// jsonFailures lists the repositories and workflows that couldn't be collected, or nil if there weren't any
+func jsonFailures(failures []client.UsageError) []jsonFailure {
+	var items []jsonFailure
+	for _, failure := range failures {
+		item := jsonFailure{Repository: repoFullName(failure.Repository), Error: failure.Reason()}
+		if failure.Repository == nil && failure.Owner != nil {
+			item.Owner = failure.Owner.Login
+		}
+		if failure.Workflow != nil {
+			item.WorkflowID = failure.Workflow.ID
+			item.WorkflowPath = failure.Workflow.Path
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+type jsonLeaderboard struct {
+	Kind          string               `json:"kind"`
+	SchemaVersion int                  `json:"schemaVersion"`
+	Host          string               `json:"host,omitempty"`
+	GroupBy       GroupBy              `json:"groupBy"`
+	Entries       []jsonLeaderEntry    `json:"entries"`
+	TotalMs       uint                 `json:"totalMs"`
+	Cost          *float64             `json:"cost,omitempty"`
+	Rest          *jsonLeaderboardRest `json:"rest,omitempty"`
+	Budgets       []jsonBudget         `json:"budgets,omitempty"`
+	Failures      []jsonFailure        `json:"failures,omitempty"`
+}
+
+type jsonLeaderEntry struct {
+	Rank            int      `json:"rank"`
+	Owner           string   `json:"owner"`
+	Repository      string   `json:"repository,omitempty"`
+	WorkflowID      *uint    `json:"workflowId,omitempty"`
+	WorkflowName    string   `json:"workflowName,omitempty"`
+	WorkflowPath    string   `json:"workflowPath,omitempty"`
+	RepositoryCount int      `json:"repositoryCount"`
+	WorkflowCount   int      `json:"workflowCount"`
+	TotalMs         uint     `json:"totalMs"`
+	Share           float64  `json:"share"`
+	Cost            *float64 `json:"cost,omitempty"`
+}
+
+// jsonLeaderboardRest counts what was left out of the leaderboard's entries, which is still included in the total
+type jsonLeaderboardRest struct {
+	Count   int  `json:"count"`
+	TotalMs uint `json:"totalMs"`
+}
+
+func (jf jsonFormatter) printLeaderboard(host string, board leaderboard, budgets []budget.Status) {
+	summary := board.Summary
+	doc := jsonLeaderboard{
+		Kind:          jsonLeaderboardKind,
+		SchemaVersion: jsonSchemaVersion,
+		Host:          host,
+		GroupBy:       board.GroupBy,
+		Entries:       make([]jsonLeaderEntry, 0, len(board.Entries)),
+		TotalMs:       board.Total,
+		Cost:          jsonCost(summary, board.Cost),
+		Budgets:       jsonBudgets(budgets),
+		Failures:      jsonFailures(summary.Failures),
+	}
+	if board.Rest.Count > 0 {
+		doc.Rest = &jsonLeaderboardRest{Count: board.Rest.Count, TotalMs: board.Rest.Usage}
+	}
+	for _, entry := range board.Entries {
+		item := jsonLeaderEntry{
+			Rank:            entry.Rank,
+			Owner:           entry.Owner,
+			Repository:      entry.Repo,
+			RepositoryCount: entry.RepoCount,
+			WorkflowCount:   entry.WorkflowCount,
+			TotalMs:         entry.Usage,
+			Share:           entry.Share,
+			Cost:            jsonCost(summary, entry.Cost),
+		}
+		if entry.Workflow != nil {
+			item.WorkflowID = &entry.Workflow.ID
+			item.WorkflowName = entry.Workflow.Name
+			item.WorkflowPath = entry.Workflow.Path
+		}
+		doc.Entries = append(doc.Entries, item)
+	}
+	jf.encode(doc)
+}
+
+type jsonHistory struct {
+	Kind          string                `json:"kind"`
+	SchemaVersion int                   `json:"schemaVersion"`
+	Snapshots     []time.Time           `json:"snapshots"`
+	Repositories  []jsonRepositoryTrend `json:"repositories"`
+}
+
+type jsonRepositoryTrend struct {
+	FullName  string              `json:"fullName"`
+	Points    []jsonTrendPoint    `json:"points"`
+	Workflows []jsonWorkflowTrend `json:"workflows"`
+}
+
+type jsonWorkflowTrend struct {
+	ID     uint             `json:"id"`
+	Name   string           `json:"name"`
+	Path   string           `json:"path"`
+	State  string           `json:"state"`
+	Points []jsonTrendPoint `json:"points"`
+}
+
+type jsonTrendPoint struct {
+	Time    time.Time   `json:"time"`
+	TotalMs uint        `json:"totalMs"`
+	Runners runnerUsage `json:"runners"`
+}
+
+func (jf jsonFormatter) PrintHistory(snapshots []history.Snapshot) {
+	summary := summarizeHistory(snapshots)
+	doc := jsonHistory{
+		Kind:          jsonHistoryKind,
+		SchemaVersion: jsonSchemaVersion,
+		Snapshots:     summary.Times,
+		Repositories:  make([]jsonRepositoryTrend, 0, len(summary.Repos)),
+	}
+	for _, repo := range summary.Repos {
+		item := jsonRepositoryTrend{FullName: repo.FullName, Points: jsonPoints(repo.Points), Workflows: make([]jsonWorkflowTrend, 0, len(repo.Workflows))}
+		for _, workflow := range repo.Workflows {
+			item.Workflows = append(item.Workflows, jsonWorkflowTrend{
+				ID:     workflow.Workflow.ID,
+				Name:   workflow.Workflow.Name,
+				Path:   workflow.Workflow.Path,
+				State:  workflow.Workflow.State,
+				Points: jsonPoints(workflow.Points),
+			})
+		}
+		doc.Repositories = append(doc.Repositories, item)
+	}
+	jf.encode(doc)
+}
+
+// jsonPoints returns the points for the snapshots that included the repository or workflow
+func jsonPoints(points []trendPoint) []jsonTrendPoint {
+	present := make([]jsonTrendPoint, 0, len(points))
+	for _, point := range points {
+		if point.Present {
+			present = append(present, jsonTrendPoint{Time: point.Time, TotalMs: point.Usage, Runners: point.Runners})
+		}
+	}
+	return present
+}
+
+func (jf jsonFormatter) encode(doc any) {
+	encoder := json.NewEncoder(jf.w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(doc)
+}
+
+func jsonRuns(summary usageSummary, runs []runSummary) []jsonRun {
+	if len(runs) == 0 {
+		return nil
+	}
+	items := make([]jsonRun, 0, len(runs))
+	for _, run := range runs {
+		items = append(items, jsonRun{
+			ID:         run.Run.ID,
+			Number:     run.Run.Number,
+			Event:      run.Run.Event,
+			Branch:     run.Run.Branch,
+			Actor:      run.Run.Actor,
+			Status:     run.Run.Status,
+			Conclusion: run.Run.Conclusion,
+			CreatedAt:  run.Run.CreatedAt,
+			DurationMs: run.Duration,
+			TotalMs:    run.Usage,
+			Runners:    run.Runners,
+			Cost:       jsonCost(summary, run.Cost),
+		})
+	}
+	return items
+}
+
+func jsonJobs(jobs []jobSummary) []jsonJob {
+	if len(jobs) == 0 {
+		return nil
+	}
+	items := make([]jsonJob, 0, len(jobs))
+	for _, job := range jobs {
+		items = append(items, jsonJob{Name: job.Name, Labels: job.Labels, RunnerGroup: job.RunnerGroup, Runs: job.Count, TotalMs: job.Usage})
+	}
+	return items
+}
+
+// jsonCost returns the cost to include in the JSON output, or nil if the summary has no cost estimates
+func jsonCost(summary usageSummary, cost float64) *float64 {
+	if !summary.HasCost {
+		return nil
+	}
+	return &cost
+}
+
+type jsonDiff struct {
+	Kind          string               `json:"kind"`
+	SchemaVersion int                  `json:"schemaVersion"`
+	Before        string               `json:"before"`
+	After         string               `json:"after"`
+	Repositories  []jsonRepositoryDiff `json:"repositories"`
+	Totals        jsonChange           `json:"totals"`
+}
+
+type jsonRepositoryDiff struct {
+	FullName string `json:"fullName"`
+	jsonChange
+	Workflows []jsonWorkflowDiff `json:"workflows"`
+}
+
+type jsonWorkflowDiff struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	State string `json:"state"`
+	jsonChange
+}
+
+type jsonChange struct {
+	Status        string   `json:"status"`
+	BeforeMs      uint     `json:"beforeMs"`
+	AfterMs       uint     `json:"afterMs"`
+	ChangeMs      int64    `json:"changeMs"`
+	PercentChange *float64 `json:"percentChange,omitempty"`
+}
+
+func (jf jsonFormatter) PrintDiff(diff Diff) {
+	summary := summarizeDiff(diff)
+	doc := jsonDiff{
+		Kind:          jsonDiffKind,
+		SchemaVersion: jsonSchemaVersion,
+		Before:        diff.BeforeName,
+		After:         diff.AfterName,
+		Repositories:  make([]jsonRepositoryDiff, 0, len(summary.Repos)),
+		Totals:        newJSONChange(summary.Total),
+	}
+	for _, repo := range summary.Repos {
+		item := jsonRepositoryDiff{FullName: repo.FullName, jsonChange: newJSONChange(repo.usageChange), Workflows: make([]jsonWorkflowDiff, 0, len(repo.Workflows))}
+		for _, workflow := range repo.Workflows {
+			item.Workflows = append(item.Workflows, jsonWorkflowDiff{
+				ID:         workflow.Workflow.ID,
+				Name:       workflow.Workflow.Name,
+				Path:       workflow.Workflow.Path,
+				State:      workflow.Workflow.State,
+				jsonChange: newJSONChange(workflow.usageChange),
+			})
+		}
+		doc.Repositories = append(doc.Repositories, item)
+	}
+	jf.encode(doc)
+}
+
+func newJSONChange(change usageChange) jsonChange {
+	item := jsonChange{Status: change.Status, BeforeMs: change.Before, AfterMs: change.After, ChangeMs: change.Delta()}
+	if pct, ok := change.Percent(); ok {
+		item.PercentChange = &pct
+	}
+	return item
+}
+
+// jsonJobMs returns the self-hosted or GitHub-hosted runner time to include in the JSON output, or nil if it wasn't
+// requested
+func jsonJobMs(summary usageSummary, ms uint) *uint {
+	if !summary.HasSelfHosted {
+		return nil
+	}
+	return &ms
+}
+
+// jsonHiddenWorkflows returns the workflows hidden by the report's filter, or nil if there weren't any
+func jsonHiddenWorkflows(hidden hiddenWorkflows) *jsonHidden {
+	if hidden.Count == 0 {
+		return nil
+	}
+	return &jsonHidden{WorkflowCount: hidden.Count, TotalMs: hidden.Usage}
+}
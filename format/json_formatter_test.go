@@ -0,0 +1,434 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/cost"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJsonFormatter(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+
+	wf := client.Workflow{ID: 7, Name: "CI", Path: ".github/workflows/ci.yml", State: "disabled_manually"}
+	wfu := client.WorkflowUsage{wf: billable(map[string]uint{"UBUNTU": 50})}
+	r := client.Repository{Owner: &client.User{Login: "codiform"}, FullName: "codiform/gh-actions-usage", Private: true}
+
+	// When
+	formatter.PrintUsage(Report{Usage: client.RepoUsage{&r: wfu}})
+
+	// Then
+	assert.JSONEq(t, `{
+  "kind": "usage",
+  "schemaVersion": 1,
+  "repositories": [
+    {
+      "fullName": "codiform/gh-actions-usage",
+      "owner": "codiform",
+      "private": true,
+      "totalMs": 50,
+      "runners": {"UBUNTU": 50},
+      "workflows": [
+        {"id": 7, "name": "CI", "path": ".github/workflows/ci.yml", "state": "disabled_manually", "totalMs": 50, "runners": {"UBUNTU": 50}}
+      ]
+    }
+  ],
+  "owners": [
+    {"login": "codiform", "repositoryCount": 1, "workflowCount": 1, "totalMs": 50, "runners": {"UBUNTU": 50}}
+  ],
+  "totals": {"repositoryCount": 1, "workflowCount": 1, "totalMs": 50, "runners": {"UBUNTU": 50}}
+}`, output.String())
+}
+
+func TestJsonFormatter_Host(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+
+	r := client.Repository{Owner: &client.User{Login: "platform"}, FullName: "platform/deployer", Private: true}
+
+	// When
+	formatter.PrintUsage(Report{Usage: client.RepoUsage{&r: {}}, Host: "github.example.com"})
+
+	// Then
+	assert.JSONEq(t, `{
+  "kind": "usage",
+  "schemaVersion": 1,
+  "host": "github.example.com",
+  "repositories": [
+    {"fullName": "platform/deployer", "owner": "platform", "private": true, "totalMs": 0, "runners": {}, "workflows": []}
+  ],
+  "owners": [
+    {"login": "platform", "repositoryCount": 1, "workflowCount": 0, "totalMs": 0, "runners": {}}
+  ],
+  "totals": {"repositoryCount": 1, "workflowCount": 0, "totalMs": 0, "runners": {}}
+}`, output.String())
+}
+
+func TestJsonFormatter_MultipleRepositories(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, Cost: cost.Default()})
+
+	// Then
+	assert.JSONEq(t, `{
+  "kind": "usage",
+  "schemaVersion": 1,
+  "repositories": [
+    {
+      "fullName": "codiform/gh-actions-usage", "owner": "codiform", "private": true,
+      "totalMs": 2000, "runners": {"MACOS": 500, "UBUNTU": 1500, "WINDOWS": 0}, "cost": 0.096,
+      "workflows": [
+        {"id": 0, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active", "totalMs": 500, "runners": {"UBUNTU": 500, "WINDOWS": 0}, "cost": 0.008},
+        {"id": 0, "name": "Release", "path": ".github/workflows/release.yml", "state": "active", "totalMs": 1500, "runners": {"MACOS": 500, "UBUNTU": 1000}, "cost": 0.088}
+      ]
+    },
+    {
+      "fullName": "codiform/terraform-tools", "owner": "codiform", "private": true,
+      "totalMs": 1000, "runners": {"WINDOWS": 1000}, "cost": 0.016,
+      "workflows": [
+        {"id": 0, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active", "totalMs": 1000, "runners": {"WINDOWS": 1000}, "cost": 0.016}
+      ]
+    },
+    {
+      "fullName": "geoffreywiseman/gh-actuse", "owner": "geoffreywiseman", "private": false,
+      "totalMs": 0, "runners": {}, "cost": 0, "workflows": []
+    }
+  ],
+  "owners": [
+    {"login": "codiform", "repositoryCount": 2, "workflowCount": 3, "totalMs": 3000, "runners": {"MACOS": 500, "UBUNTU": 1500, "WINDOWS": 1000}, "cost": 0.112},
+    {"login": "geoffreywiseman", "repositoryCount": 1, "workflowCount": 0, "totalMs": 0, "runners": {}, "cost": 0}
+  ],
+  "totals": {"repositoryCount": 3, "workflowCount": 3, "totalMs": 3000, "runners": {"MACOS": 500, "UBUNTU": 1500, "WINDOWS": 1000}, "cost": 0.112}
+}`, output.String())
+}
+
+func TestJsonFormatter_History(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+
+	// When
+	formatter.PrintHistory(sampleHistory())
+
+	// Then
+	assert.JSONEq(t, `{
+  "kind": "history",
+  "schemaVersion": 1,
+  "snapshots": ["2026-09-01T12:00:00Z", "2026-10-01T12:00:00Z"],
+  "repositories": [
+    {
+      "fullName": "codiform/gh-actions-usage",
+      "points": [
+        {"time": "2026-09-01T12:00:00Z", "totalMs": 60000, "runners": {"UBUNTU": 60000}},
+        {"time": "2026-10-01T12:00:00Z", "totalMs": 120000, "runners": {"MACOS": 30000, "UBUNTU": 90000}}
+      ],
+      "workflows": [
+        {
+          "id": 1, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active",
+          "points": [
+            {"time": "2026-09-01T12:00:00Z", "totalMs": 60000, "runners": {"UBUNTU": 60000}},
+            {"time": "2026-10-01T12:00:00Z", "totalMs": 90000, "runners": {"UBUNTU": 90000}}
+          ]
+        },
+        {
+          "id": 2, "name": "Release", "path": ".github/workflows/release.yml", "state": "active",
+          "points": [
+            {"time": "2026-10-01T12:00:00Z", "totalMs": 30000, "runners": {"MACOS": 30000}}
+          ]
+        }
+      ]
+    }
+  ]
+}`, output.String())
+}
+
+func TestJsonFormatter_Diff(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+
+	// When
+	formatter.PrintDiff(sampleDiff())
+
+	// Then
+	assert.JSONEq(t, `{
+  "kind": "diff",
+  "schemaVersion": 1,
+  "before": "before.json",
+  "after": "after.json",
+  "repositories": [
+    {
+      "fullName": "codiform/gh-actions-usage",
+      "status": "changed", "beforeMs": 70000, "afterMs": 120000, "changeMs": 50000, "percentChange": 71.42857142857143,
+      "workflows": [
+        {"id": 1, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active", "status": "changed", "beforeMs": 60000, "afterMs": 90000, "changeMs": 30000, "percentChange": 50},
+        {"id": 3, "name": "Old", "path": ".github/workflows/old.yml", "state": "disabled_manually", "status": "removed", "beforeMs": 10000, "afterMs": 0, "changeMs": -10000, "percentChange": -100},
+        {"id": 2, "name": "Release", "path": ".github/workflows/release.yml", "state": "active", "status": "new", "beforeMs": 0, "afterMs": 30000, "changeMs": 30000}
+      ]
+    },
+    {
+      "fullName": "codiform/terraform-tools",
+      "status": "removed", "beforeMs": 1000, "afterMs": 0, "changeMs": -1000, "percentChange": -100,
+      "workflows": [
+        {"id": 4, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active", "status": "removed", "beforeMs": 1000, "afterMs": 0, "changeMs": -1000, "percentChange": -100}
+      ]
+    }
+  ],
+  "totals": {"status": "changed", "beforeMs": 71000, "afterMs": 120000, "changeMs": 49000, "percentChange": 69.01408450704226}
+}`, output.String())
+}
+
+func TestReadJSONReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		report Report
+	}{
+		{"all", Report{Failures: []client.UsageError{{Repository: &client.Repository{FullName: "codiform/broken"}, Err: errBilling}}}},
+		{"filtered", Report{Workflows: WorkflowFilter{Paths: []string{"ci.yml"}}}},
+		{"top", Report{Order: Order{Key: SortByUsage, Descending: true, Top: 1}}},
+		{"top repos", Report{Order: Order{Key: SortByUsage, Descending: true, TopRepos: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			var saved bytes.Buffer
+			tt.report.Usage = sampleMultipleRepositoriesUsage()
+			jsonFormatter{&saved}.PrintUsage(tt.report)
+
+			// When
+			usage, err := ReadJSONReport(&saved)
+
+			// Then
+			require.NoError(t, err)
+			var roundTrip bytes.Buffer
+			humanFormatter{&roundTrip}.PrintUsage(Report{Usage: usage})
+			var original bytes.Buffer
+			humanFormatter{&original}.PrintUsage(Report{Usage: sampleMultipleRepositoriesUsage()})
+			assert.Equal(t, original.String(), roundTrip.String())
+		})
+	}
+}
+
+func TestReadJSONReport_UnsupportedSchema(t *testing.T) {
+	// When
+	usage, err := ReadJSONReport(strings.NewReader(`{"schemaVersion": 2, "repositories": []}`))
+
+	// Then
+	require.ErrorIs(t, err, UnsupportedSchemaError(2))
+	assert.Nil(t, usage)
+}
+
+func TestReadJSONReport_NotUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		print func(jf jsonFormatter)
+		kind  string
+	}{
+		{"leaderboard", func(jf jsonFormatter) {
+			jf.PrintUsage(Report{Usage: sampleMultipleRepositoriesUsage(), GroupBy: GroupByRepo})
+		}, jsonLeaderboardKind},
+		{"history", func(jf jsonFormatter) { jf.PrintHistory(sampleHistory()) }, jsonHistoryKind},
+		{"diff", func(jf jsonFormatter) { jf.PrintDiff(sampleDiff()) }, jsonDiffKind},
+		{"no kind", func(jf jsonFormatter) { _, _ = jf.w.Write([]byte(`{"schemaVersion": 1, "repositories": []}`)) }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved bytes.Buffer
+			tt.print(jsonFormatter{&saved})
+
+			usage, err := ReadJSONReport(&saved)
+
+			require.ErrorIs(t, err, NotUsageReportError(tt.kind))
+			assert.Nil(t, usage)
+		})
+	}
+}
+
+func TestJsonFormatter_Runs(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+
+	// When
+	formatter.PrintUsage(Report{Usage: sampleRunsUsage(), Runs: true})
+
+	// Then
+	assert.JSONEq(t, `{
+  "kind": "usage",
+  "schemaVersion": 1,
+  "repositories": [
+    {
+      "fullName": "codiform/gh-actions-usage", "owner": "codiform", "private": true, "totalMs": 150000, "runners": {"UBUNTU": 150000},
+      "workflows": [
+        {
+          "id": 1, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active", "totalMs": 150000, "runners": {"UBUNTU": 150000},
+          "runs": [
+            {"id": 10, "number": 1, "event": "push", "branch": "main", "actor": "geoffreywiseman", "status": "completed", "conclusion": "success",
+             "createdAt": "2026-10-01T09:00:00Z", "durationMs": 90000, "totalMs": 120000, "runners": {"UBUNTU": 120000}},
+            {"id": 11, "number": 2, "event": "pull_request", "branch": "feature", "actor": "octocat", "status": "in_progress", "conclusion": "",
+             "createdAt": "2026-10-02T09:00:00Z", "durationMs": 30000, "totalMs": 30000, "runners": {"UBUNTU": 30000}}
+          ],
+          "jobs": [
+            {"name": "build", "labels": ["ubuntu-latest"], "runnerGroup": "GitHub Actions", "runs": 2, "totalMs": 130000},
+            {"name": "lint", "labels": ["ubuntu-latest"], "runnerGroup": "GitHub Actions", "runs": 1, "totalMs": 20000}
+          ]
+        },
+        {
+          "id": 2, "name": "Deploy", "path": ".github/workflows/deploy.yml", "state": "active", "totalMs": 0, "runners": {},
+          "runs": [
+            {"id": 20, "number": 1, "event": "push", "branch": "main", "actor": "octocat", "status": "completed", "conclusion": "success",
+             "createdAt": "2026-10-03T09:00:00Z", "durationMs": 300000, "totalMs": 0, "runners": {}}
+          ],
+          "jobs": [
+            {"name": "deploy", "labels": ["self-hosted", "linux"], "runnerGroup": "Fleet", "runs": 1, "totalMs": 300000}
+          ]
+        }
+      ]
+    }
+  ],
+  "owners": [
+    {"login": "codiform", "repositoryCount": 1, "workflowCount": 2, "totalMs": 150000, "runners": {"UBUNTU": 150000}}
+  ],
+  "totals": {"repositoryCount": 1, "workflowCount": 2, "totalMs": 150000, "runners": {"UBUNTU": 150000}}
+}`, output.String())
+}
+
+func TestJsonFormatter_Jobs(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+
+	// When
+	formatter.PrintUsage(Report{Usage: sampleRunsUsage()})
+
+	// Then
+	var doc struct {
+		Repositories []struct {
+			Workflows []struct {
+				Path string            `json:"path"`
+				Jobs []json.RawMessage `json:"jobs"`
+			} `json:"workflows"`
+		} `json:"repositories"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &doc))
+	require.Len(t, doc.Repositories, 1)
+	workflows := doc.Repositories[0].Workflows
+	require.Len(t, workflows, 2)
+	require.Len(t, workflows[0].Jobs, 2)
+	assert.JSONEq(t, `{"name": "build", "labels": ["ubuntu-latest"], "runnerGroup": "GitHub Actions", "runs": 2, "totalMs": 130000}`, string(workflows[0].Jobs[0]))
+	assert.JSONEq(t, `{"name": "deploy", "labels": ["self-hosted", "linux"], "runnerGroup": "Fleet", "runs": 1, "totalMs": 300000}`, string(workflows[1].Jobs[0]))
+}
+
+func TestJsonFormatter_SelfHosted(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+
+	// When
+	formatter.PrintUsage(Report{Usage: sampleRunsUsage(), SelfHosted: true})
+
+	// Then
+	var doc struct {
+		Repositories []struct {
+			Workflows []struct {
+				SelfHostedMs   uint `json:"selfHostedMs"`
+				GitHubHostedMs uint `json:"githubHostedMs"`
+			} `json:"workflows"`
+		} `json:"repositories"`
+		Totals struct {
+			SelfHostedMs   uint `json:"selfHostedMs"`
+			GitHubHostedMs uint `json:"githubHostedMs"`
+		} `json:"totals"`
+	}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &doc))
+	require.Len(t, doc.Repositories, 1)
+	workflows := doc.Repositories[0].Workflows
+	require.Len(t, workflows, 2)
+	assert.Equal(t, uint(141000), workflows[0].GitHubHostedMs)
+	assert.Equal(t, uint(300000), workflows[1].SelfHostedMs)
+	assert.Equal(t, uint(300000), doc.Totals.SelfHostedMs)
+	assert.Equal(t, uint(141000), doc.Totals.GitHubHostedMs)
+}
+
+func TestJsonFormatter_HiddenWorkflows(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, Workflows: WorkflowFilter{Paths: []string{"ci.yml"}}})
+
+	// Then
+	assert.JSONEq(t, `{
+  "kind": "usage",
+  "schemaVersion": 1,
+  "repositories": [
+    {
+      "fullName": "codiform/gh-actions-usage", "owner": "codiform", "private": true,
+      "totalMs": 2000, "runners": {"MACOS": 500, "UBUNTU": 1500, "WINDOWS": 0},
+      "hidden": {"workflowCount": 1, "totalMs": 1500},
+      "workflows": [
+        {"id": 0, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active", "totalMs": 500, "runners": {"UBUNTU": 500, "WINDOWS": 0}},
+        {"id": 0, "name": "Release", "path": ".github/workflows/release.yml", "state": "active", "totalMs": 1500, "runners": {"MACOS": 500, "UBUNTU": 1000},
+         "hidden": true}
+      ]
+    },
+    {
+      "fullName": "codiform/terraform-tools", "owner": "codiform", "private": true,
+      "totalMs": 1000, "runners": {"WINDOWS": 1000},
+      "workflows": [
+        {"id": 0, "name": "CI", "path": ".github/workflows/ci.yml", "state": "active", "totalMs": 1000, "runners": {"WINDOWS": 1000}}
+      ]
+    },
+    {
+      "fullName": "geoffreywiseman/gh-actuse", "owner": "geoffreywiseman", "private": false,
+      "totalMs": 0, "runners": {}, "workflows": []
+    }
+  ],
+  "owners": [
+    {"login": "codiform", "repositoryCount": 2, "workflowCount": 3, "totalMs": 3000, "runners": {"MACOS": 500, "UBUNTU": 1500, "WINDOWS": 1000},
+     "hidden": {"workflowCount": 1, "totalMs": 1500}},
+    {"login": "geoffreywiseman", "repositoryCount": 1, "workflowCount": 0, "totalMs": 0, "runners": {}}
+  ],
+  "totals": {"repositoryCount": 3, "workflowCount": 3, "totalMs": 3000, "runners": {"MACOS": 500, "UBUNTU": 1500, "WINDOWS": 1000},
+    "hidden": {"workflowCount": 1, "totalMs": 1500}}
+}`, output.String())
+}
+
+func TestJsonFormatter_GroupByWorkflow(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := jsonFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, GroupBy: GroupByWorkflow, Order: Order{Top: 2}})
+
+	// Then
+	assert.JSONEq(t, `{
+  "kind": "leaderboard",
+  "schemaVersion": 1,
+  "groupBy": "workflow",
+  "entries": [
+    {"rank": 1, "owner": "codiform", "repository": "codiform/gh-actions-usage", "workflowId": 0, "workflowName": "Release",
+     "workflowPath": ".github/workflows/release.yml", "repositoryCount": 1, "workflowCount": 1, "totalMs": 1500, "share": 50},
+    {"rank": 2, "owner": "codiform", "repository": "codiform/terraform-tools", "workflowId": 0, "workflowName": "CI",
+     "workflowPath": ".github/workflows/ci.yml", "repositoryCount": 1, "workflowCount": 1, "totalMs": 1000, "share": 33.333333333333336}
+  ],
+  "totalMs": 3000,
+  "rest": {"count": 1, "totalMs": 500}
+}`, output.String())
+}
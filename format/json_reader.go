@@ -0,0 +1,67 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// UnsupportedSchemaError is an error when a saved JSON report has a schema version that can't be read
+type UnsupportedSchemaError int
+
+// Error returns a formatted error message for UnsupportedSchemaError
+func (e UnsupportedSchemaError) Error() string {
+	return fmt.Sprintf("Unsupported JSON report schema version: %d (expected %d)", int(e), jsonSchemaVersion)
+}
+
+// NotUsageReportError is an error when a saved JSON document isn't a usage report, e.g. a leaderboard saved
+// with --group-by, or the output of history or diff
+type NotUsageReportError string
+
+// Error returns a formatted error message for NotUsageReportError
+func (e NotUsageReportError) Error() string {
+	if e == "" {
+		return "Not a JSON usage report: it has no kind"
+	}
+	return fmt.Sprintf("Not a JSON usage report: it's a %s", string(e))
+}
+
+// ReadJSONReport reads the usage back from a report saved with the JSON formatter, so that it can be compared
+// with another report; the rollups and failures in the report are left out, since they're derived from the usage
+func ReadJSONReport(r io.Reader) (client.RepoUsage, error) {
+	var doc jsonReport
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not read JSON report: %w", err)
+	}
+	if doc.SchemaVersion != jsonSchemaVersion {
+		return nil, UnsupportedSchemaError(doc.SchemaVersion)
+	}
+	if doc.Kind != jsonUsageKind {
+		return nil, NotUsageReportError(doc.Kind)
+	}
+
+	usage := make(client.RepoUsage, len(doc.Repositories))
+	owners := make(map[string]*client.User)
+	for _, repo := range doc.Repositories {
+		owner := owners[repo.Owner]
+		if owner == nil {
+			owner = &client.User{Login: repo.Owner}
+			owners[repo.Owner] = owner
+		}
+		_, name, _ := strings.Cut(repo.FullName, "/")
+		flowUsage := make(client.WorkflowUsage, len(repo.Workflows))
+		for _, flow := range repo.Workflows {
+			billable := make(map[string]*client.UsageDetails, len(flow.Runners))
+			for env, ms := range flow.Runners {
+				billable[env] = &client.UsageDetails{TotalMs: ms}
+			}
+			workflow := client.Workflow{ID: flow.ID, Name: flow.Name, Path: flow.Path, State: flow.State}
+			flowUsage[workflow] = &client.Usage{Billable: billable}
+		}
+		usage[&client.Repository{Owner: owner, FullName: repo.FullName, Name: name, Private: repo.Private}] = flowUsage
+	}
+	return usage, nil
+}
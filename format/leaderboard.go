@@ -0,0 +1,126 @@
+package format
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// GroupBy is how usage is ranked in a leaderboard, instead of being listed by repository
+type GroupBy string
+
+// The groupings that a report can be ranked by
+const (
+	// GroupByWorkflow ranks every workflow across all the repositories
+	GroupByWorkflow GroupBy = "workflow"
+	// GroupByRepo ranks the repositories
+	GroupByRepo GroupBy = "repo"
+	// GroupByOwner ranks the users and organizations that own the repositories
+	GroupByOwner GroupBy = "owner"
+)
+
+// InvalidGroupByError is an error when the grouping isn't one of the supported groupings
+type InvalidGroupByError string
+
+// Error returns a formatted error message for InvalidGroupByError
+func (e InvalidGroupByError) Error() string {
+	return fmt.Sprintf("Invalid group by: %s (must be workflow, repo or owner)", string(e))
+}
+
+// ParseGroupBy returns the grouping with the specified name; an empty name lists usage by repository
+func ParseGroupBy(value string) (GroupBy, error) {
+	switch group := GroupBy(value); group {
+	case "", GroupByWorkflow, GroupByRepo, GroupByOwner:
+		return group, nil
+	default:
+		return "", InvalidGroupByError(value)
+	}
+}
+
+// plural is the name of what's being ranked, e.g. "workflows"
+func (g GroupBy) plural() string {
+	switch g {
+	case GroupByRepo:
+		return "repositories"
+	case GroupByOwner:
+		return "owners"
+	default:
+		return "workflows"
+	}
+}
+
+// leaderEntry is one row of a leaderboard: a workflow, repository or owner, depending on the grouping
+type leaderEntry struct {
+	Rank  int
+	Owner string
+	// Repo is empty when ranking owners
+	Repo string
+	// Workflow is only set when ranking workflows
+	Workflow      *client.Workflow
+	RepoCount     int
+	WorkflowCount int
+	Usage         uint
+	Cost          float64
+	// Share is the percentage of the total usage
+	Share float64
+}
+
+// leaderboard ranks the workflows, repositories or owners in a report by usage, most first
+type leaderboard struct {
+	GroupBy GroupBy
+	Entries []leaderEntry
+	// Total is the usage of everything in the report, including what isn't ranked
+	Total uint
+	Cost  float64
+	// Rest counts what was left out of the entries by the report's filter or top, and its usage
+	Rest    hiddenWorkflows
+	Summary usageSummary
+}
+
+// summarizeLeaderboard ranks the report by its grouping, reusing the repository and workflow summaries; the
+// report's top limits the number of entries rather than the workflows in each repository
+func summarizeLeaderboard(report Report) leaderboard {
+	top := report.Order.Top
+	report.Order = Order{}
+	summary := summarizeUsage(report)
+	board := leaderboard{GroupBy: report.GroupBy, Total: summary.Total, Cost: summary.Cost, Summary: summary}
+
+	switch report.GroupBy {
+	case GroupByOwner:
+		for _, owner := range summary.Owners {
+			board.Entries = append(board.Entries, leaderEntry{Owner: owner.Owner, RepoCount: owner.RepoCount,
+				WorkflowCount: owner.WorkflowCount, Usage: owner.Total, Cost: owner.Cost})
+		}
+	case GroupByRepo:
+		for _, repo := range summary.Repos {
+			board.Entries = append(board.Entries, leaderEntry{Owner: repo.Owner, Repo: repo.Repo.FullName, RepoCount: 1,
+				WorkflowCount: repo.workflowCount(), Usage: repo.Total, Cost: repo.Cost})
+		}
+	default:
+		for _, repo := range summary.Repos {
+			for _, workflow := range repo.Workflows {
+				board.Entries = append(board.Entries, leaderEntry{Owner: repo.Owner, Repo: repo.Repo.FullName,
+					Workflow: &workflow.Workflow, RepoCount: 1, WorkflowCount: 1, Usage: workflow.Usage, Cost: workflow.Cost})
+			}
+		}
+		board.Rest = summary.Hidden
+	}
+
+	sort.SliceStable(board.Entries, func(i, j int) bool {
+		return board.Entries[i].Usage > board.Entries[j].Usage
+	})
+	if top > 0 && len(board.Entries) > top {
+		for _, entry := range board.Entries[top:] {
+			board.Rest.add(hiddenWorkflows{Count: 1, Usage: entry.Usage})
+		}
+		board.Entries = board.Entries[:top]
+	}
+	for i := range board.Entries {
+		board.Entries[i].Rank = i + 1
+		if board.Total > 0 {
+			board.Entries[i].Share = float64(board.Entries[i].Usage) * percent / float64(board.Total)
+		}
+	}
+	return board
+}
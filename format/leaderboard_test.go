@@ -0,0 +1,55 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	group, err := ParseGroupBy("repo")
+	require.NoError(t, err)
+	assert.Equal(t, GroupByRepo, group)
+
+	group, err = ParseGroupBy("")
+	require.NoError(t, err)
+	assert.Equal(t, GroupBy(""), group)
+
+	_, err = ParseGroupBy("team")
+	assert.Equal(t, InvalidGroupByError("team"), err)
+}
+
+func TestSummarizeLeaderboard_Workflows(t *testing.T) {
+	// Given
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	board := summarizeLeaderboard(Report{Usage: ru, GroupBy: GroupByWorkflow})
+
+	// Then
+	require.Len(t, board.Entries, 3)
+	assert.Equal(t, uint(3000), board.Total)
+	assert.Equal(t, 1, board.Entries[0].Rank)
+	assert.Equal(t, "codiform/gh-actions-usage", board.Entries[0].Repo)
+	assert.Equal(t, "Release", board.Entries[0].Workflow.Name)
+	assert.InDelta(t, 50.0, board.Entries[0].Share, 0.001)
+	assert.Equal(t, "codiform/terraform-tools", board.Entries[1].Repo)
+	assert.Equal(t, "codiform/gh-actions-usage", board.Entries[2].Repo)
+	assert.Equal(t, ".github/workflows/ci.yml", board.Entries[2].Workflow.Path)
+	assert.Equal(t, hiddenWorkflows{}, board.Rest)
+}
+
+func TestSummarizeLeaderboard_Top(t *testing.T) {
+	// Given
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	board := summarizeLeaderboard(Report{Usage: ru, GroupBy: GroupByWorkflow, Order: Order{Top: 1}, Workflows: WorkflowFilter{MinUsage: 1000}})
+
+	// Then
+	require.Len(t, board.Entries, 1)
+	assert.Equal(t, "Release", board.Entries[0].Workflow.Name)
+	assert.Equal(t, uint(3000), board.Total)
+	assert.Equal(t, hiddenWorkflows{Count: 2, Usage: 1500}, board.Rest)
+}
@@ -0,0 +1,198 @@
+package format
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKey is what repositories and workflows are sorted by
+type SortKey string
+
+// The keys that reports can be sorted by
+const (
+	// SortByName sorts repositories by full name and workflows by path
+	SortByName SortKey = "name"
+	// SortByUsage sorts repositories and workflows by their usage
+	SortByUsage SortKey = "usage"
+	// SortByWorkflows sorts repositories by how many workflows they have, and workflows by path
+	SortByWorkflows SortKey = "workflows"
+)
+
+// Order decides how the repositories and workflows in a report are sorted, and how many of them are shown
+type Order struct {
+	Key        SortKey
+	Descending bool
+	// Top is the number of workflows to show across all the repositories, in order, or 0 to show them all; only
+	// the repositories with those workflows are shown, but the others are still counted in the totals
+	Top int
+	// TopRepos is the number of repositories to show, in order, or 0 to show them all; the workflows of the others
+	// are hidden, but still counted in the totals
+	TopRepos int
+}
+
+// InvalidSortError is an error when the sort order isn't one of the supported keys and directions
+type InvalidSortError string
+
+// Error returns a formatted error message for InvalidSortError
+func (e InvalidSortError) Error() string {
+	return fmt.Sprintf("Invalid sort: %s (must be name, usage or workflows, optionally followed by :asc or :desc)", string(e))
+}
+
+// InvalidTopError is an error when the number of workflows or repositories to show is negative
+type InvalidTopError int
+
+// Error returns a formatted error message for InvalidTopError
+func (e InvalidTopError) Error() string {
+	return fmt.Sprintf("Invalid top: %d (must not be negative)", int(e))
+}
+
+// ParseOrder builds an order from a sort key with an optional direction (e.g. usage or name:desc) and the number
+// of workflows and repositories to show. Names sort ascending by default, usage and workflow counts sort
+// descending. Without a sort key, a top shows the workflows or repositories that used the most time, and
+// everything else is sorted by name.
+func ParseOrder(value string, top, topRepos int) (Order, error) {
+	if top < 0 {
+		return Order{}, InvalidTopError(top)
+	}
+	if topRepos < 0 {
+		return Order{}, InvalidTopError(topRepos)
+	}
+	if value == "" {
+		value = string(SortByName)
+		if top > 0 || topRepos > 0 {
+			value = string(SortByUsage)
+		}
+	}
+	key, direction, _ := strings.Cut(value, ":")
+	order := Order{Key: SortKey(key), Top: top, TopRepos: topRepos}
+	switch order.Key {
+	case SortByName:
+	case SortByUsage, SortByWorkflows:
+		order.Descending = true
+	default:
+		return Order{}, InvalidSortError(value)
+	}
+	switch direction {
+	case "":
+	case "asc":
+		order.Descending = false
+	case "desc":
+		order.Descending = true
+	default:
+		return Order{}, InvalidSortError(value)
+	}
+	return order, nil
+}
+
+// sortRepos sorts the repositories, and the workflows in each of them; ties are broken by name
+func (o Order) sortRepos(repos []repoSummary) {
+	for _, repo := range repos {
+		sort.SliceStable(repo.Workflows, func(i, j int) bool {
+			return o.workflowLess(repo.Workflows[i], repo.Workflows[j])
+		})
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		a, b := repos[i], repos[j]
+		switch {
+		case o.Key == SortByUsage && a.Total != b.Total:
+			return (a.Total > b.Total) == o.Descending
+		case o.Key == SortByWorkflows && a.workflowCount() != b.workflowCount():
+			return (a.workflowCount() > b.workflowCount()) == o.Descending
+		case (o.Key == "" || o.Key == SortByName) && o.Descending:
+			return a.Repo.FullName > b.Repo.FullName
+		default:
+			return a.Repo.FullName < b.Repo.FullName
+		}
+	})
+}
+
+func (o Order) workflowLess(a, b workflowSummary) bool {
+	switch {
+	case o.Key == SortByUsage && a.Usage != b.Usage:
+		return (a.Usage > b.Usage) == o.Descending
+	case (o.Key == "" || o.Key == SortByName) && o.Descending:
+		return workflowLess(b.Workflow, a.Workflow)
+	default:
+		return workflowLess(a.Workflow, b.Workflow)
+	}
+}
+
+// hideBeyondTopRepos hides the workflows of the repositories after the top ones
+func (o Order) hideBeyondTopRepos(repos []repoSummary) {
+	if o.TopRepos == 0 || len(repos) <= o.TopRepos {
+		return
+	}
+	for i := o.TopRepos; i < len(repos); i++ {
+		repo := &repos[i]
+		for _, workflow := range repo.Workflows {
+			repo.Hidden.add(hiddenWorkflows{Count: 1, Usage: workflow.Usage})
+			repo.HiddenWorkflows = append(repo.HiddenWorkflows, workflow)
+		}
+		repo.Workflows = nil
+		repo.Jobs = nil
+	}
+}
+
+// hideBeyondTop hides the workflows after the top ones across all of the sorted repositories, which are ranked by
+// usage when sorting by usage and in the order they're listed otherwise
+func (o Order) hideBeyondTop(repos []repoSummary) {
+	if o.Top == 0 {
+		return
+	}
+	type rank struct {
+		repo, workflow int
+		usage          uint
+	}
+	var ranks []rank
+	for i, repo := range repos {
+		for j, workflow := range repo.Workflows {
+			ranks = append(ranks, rank{repo: i, workflow: j, usage: workflow.Usage})
+		}
+	}
+	if len(ranks) <= o.Top {
+		return
+	}
+	if o.Key == SortByUsage {
+		sort.SliceStable(ranks, func(i, j int) bool {
+			return ranks[i].usage != ranks[j].usage && (ranks[i].usage > ranks[j].usage) == o.Descending
+		})
+	}
+	kept := make(map[[2]int]bool, o.Top)
+	for _, r := range ranks[:o.Top] {
+		kept[[2]int{r.repo, r.workflow}] = true
+	}
+	for i := range repos {
+		repo := &repos[i]
+		shown := make([]workflowSummary, 0, len(repo.Workflows))
+		for j, workflow := range repo.Workflows {
+			if kept[[2]int{i, j}] {
+				shown = append(shown, workflow)
+			} else {
+				repo.Hidden.add(hiddenWorkflows{Count: 1, Usage: workflow.Usage})
+				repo.HiddenWorkflows = append(repo.HiddenWorkflows, workflow)
+			}
+		}
+		if len(shown) < len(repo.Workflows) {
+			repo.Workflows = shown
+			repo.Jobs = workflowJobs(shown)
+		}
+	}
+}
+
+// topRepos splits the repositories into those that are shown and those that are left out, because they're beyond
+// the top repositories or have none of the top workflows
+func (o Order) topRepos(repos []repoSummary) (shown, omitted []repoSummary) {
+	if o.Top == 0 && o.TopRepos == 0 {
+		return repos, nil
+	}
+	shown = make([]repoSummary, 0, len(repos))
+	for i, repo := range repos {
+		if (o.TopRepos > 0 && i >= o.TopRepos) || (o.Top > 0 && len(repo.Workflows) == 0) {
+			omitted = append(omitted, repo)
+		} else {
+			shown = append(shown, repo)
+		}
+	}
+	return shown, omitted
+}
@@ -0,0 +1,122 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		top      int
+		topRepos int
+		expected Order
+	}{
+		{"name", "name", 0, 0, Order{Key: SortByName}},
+		{"name:desc", "name:desc", 0, 0, Order{Key: SortByName, Descending: true}},
+		{"usage", "usage", 0, 0, Order{Key: SortByUsage, Descending: true}},
+		{"usage:asc", "usage:asc", 0, 0, Order{Key: SortByUsage}},
+		{"workflows", "workflows", 0, 0, Order{Key: SortByWorkflows, Descending: true}},
+		{"default", "", 0, 0, Order{Key: SortByName}},
+		{"default top", "", 20, 0, Order{Key: SortByUsage, Descending: true, Top: 20}},
+		{"default top repos", "", 0, 5, Order{Key: SortByUsage, Descending: true, TopRepos: 5}},
+		{"name top", "name", 20, 5, Order{Key: SortByName, Top: 20, TopRepos: 5}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			order, err := ParseOrder(test.value, test.top, test.topRepos)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, order)
+		})
+	}
+}
+
+func TestParseOrder_Invalid(t *testing.T) {
+	_, err := ParseOrder("cost", 0, 0)
+	assert.Equal(t, InvalidSortError("cost"), err)
+
+	_, err = ParseOrder("usage:up", 0, 0)
+	assert.Equal(t, InvalidSortError("usage:up"), err)
+
+	_, err = ParseOrder("usage", -1, 0)
+	assert.Equal(t, InvalidTopError(-1), err)
+
+	_, err = ParseOrder("usage", 0, -2)
+	assert.Equal(t, InvalidTopError(-2), err)
+}
+
+func TestSummarizeUsage_Order(t *testing.T) {
+	// Given
+	ru := sampleMultipleRepositoriesUsage()
+	order, err := ParseOrder("usage:asc", 0, 0)
+	require.NoError(t, err)
+
+	// When
+	summary := summarizeUsage(Report{Usage: ru, Order: order})
+
+	// Then
+	names := make([]string, 0, len(summary.Repos))
+	for _, repo := range summary.Repos {
+		names = append(names, repo.Repo.FullName)
+	}
+	assert.Equal(t, []string{"geoffreywiseman/gh-actuse", "codiform/terraform-tools", "codiform/gh-actions-usage"}, names)
+	assert.Equal(t, "CI", summary.Repos[2].Workflows[0].Workflow.Name)
+	assert.Equal(t, "Release", summary.Repos[2].Workflows[1].Workflow.Name)
+}
+
+func TestSummarizeUsage_ByWorkflows(t *testing.T) {
+	// Given
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	summary := summarizeUsage(Report{Usage: ru, Order: Order{Key: SortByWorkflows, Descending: true}})
+
+	// Then
+	assert.Equal(t, "codiform/gh-actions-usage", summary.Repos[0].Repo.FullName)
+	assert.Equal(t, "codiform/terraform-tools", summary.Repos[1].Repo.FullName)
+	assert.Equal(t, "geoffreywiseman/gh-actuse", summary.Repos[2].Repo.FullName)
+}
+
+func TestSummarizeUsage_Top(t *testing.T) {
+	// Given
+	ru := sampleMultipleRepositoriesUsage()
+	order, err := ParseOrder("usage", 2, 0)
+	require.NoError(t, err)
+
+	// When
+	summary := summarizeUsage(Report{Usage: ru, Order: order})
+
+	// Then
+	require.Len(t, summary.Repos, 2)
+	assert.Equal(t, "codiform/gh-actions-usage", summary.Repos[0].Repo.FullName)
+	require.Len(t, summary.Repos[0].Workflows, 1)
+	assert.Equal(t, "Release", summary.Repos[0].Workflows[0].Workflow.Name)
+	assert.Equal(t, hiddenWorkflows{Count: 1, Usage: 500}, summary.Repos[0].Hidden)
+	assert.Equal(t, "codiform/terraform-tools", summary.Repos[1].Repo.FullName)
+	assert.Equal(t, 3, summary.RepoCount)
+	assert.Equal(t, uint(3000), summary.Total)
+	assert.Equal(t, hiddenWorkflows{Count: 1, Usage: 500}, summary.Hidden)
+}
+
+func TestSummarizeUsage_TopRepos(t *testing.T) {
+	// Given
+	ru := sampleMultipleRepositoriesUsage()
+	order, err := ParseOrder("", 0, 1)
+	require.NoError(t, err)
+
+	// When
+	summary := summarizeUsage(Report{Usage: ru, Order: order})
+
+	// Then
+	require.Len(t, summary.Repos, 1)
+	assert.Equal(t, "codiform/gh-actions-usage", summary.Repos[0].Repo.FullName)
+	assert.Len(t, summary.Repos[0].Workflows, 2)
+	require.Len(t, summary.OmittedRepos, 2)
+	assert.Equal(t, "codiform/terraform-tools", summary.OmittedRepos[0].Repo.FullName)
+	assert.Equal(t, 3, summary.RepoCount)
+	assert.Equal(t, uint(3000), summary.Total)
+	assert.Equal(t, hiddenWorkflows{Count: 1, Usage: 1000}, summary.Hidden)
+}
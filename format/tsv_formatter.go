@@ -4,43 +4,290 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
 	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/history"
 )
 
+// standardRunners are the runner environments that always have a TSV column, so that the columns
+// are stable from one report to the next; any other environments in the usage are added after them
+var standardRunners = []string{"MACOS", "UBUNTU", "WINDOWS"}
+
 type tsvFormatter struct {
 	w io.Writer
 }
 
-func (tf tsvFormatter) PrintUsage(usage client.RepoUsage) {
-	_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\n", "Repo", "Workflow", "Milliseconds")
-	repos := sortedRepositories(usage)
-	for _, repo := range repos {
-		workflows := sortedWorkflowUsage(usage[repo])
-		if len(workflows) == 0 {
-			_, _ = fmt.Fprintf(tf.w, "%s\tn/a\t0\n", repoFullName(repo))
+func (tf tsvFormatter) PrintUsage(report Report) {
+	if report.GroupBy != "" {
+		board := summarizeLeaderboard(report)
+		tf.printLeaderboard(board)
+		tf.printBudgets(CheckBudgets(report))
+		tf.printFailures(board.Summary)
+		tf.printHost(report.Host)
+		return
+	}
+	summary := summarizeUsage(report)
+	runners := runnerColumns(report.Usage)
+	extraColumns := ""
+	if summary.HasSelfHosted {
+		extraColumns += "\tSelf-Hosted\tGitHub-Hosted"
+	}
+	if summary.HasCost {
+		extraColumns += "\tCost"
+	}
+	_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\t%s%s\n", "Repo", "Workflow", "Milliseconds", strings.Join(runners, "\t"), extraColumns)
+	for _, repo := range summary.Repos {
+		if len(repo.Workflows) == 0 && repo.Hidden.Count == 0 {
+			_, _ = fmt.Fprintf(tf.w, "%s\tn/a\t0%s%s%s\n", repo.Repo.FullName, strings.Repeat("\t0", len(runners)), tsvJobTime(summary, jobTime{}), tsvCost(summary, 0))
+			continue
+		}
+		for _, workflow := range repo.Workflows {
+			_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%d", repo.Repo.FullName, workflow.Workflow.Path, workflow.Usage)
+			for _, env := range runners {
+				_, _ = fmt.Fprintf(tf.w, "\t%d", workflow.Runners[env])
+			}
+			_, _ = fmt.Fprintf(tf.w, "%s%s\n", tsvJobTime(summary, workflow.JobTime), tsvCost(summary, workflow.Cost))
+		}
+		if repo.Hidden.Count > 0 {
+			tf.printHidden(summary, repo, runners)
+		}
+	}
+	tf.printRuns(summary)
+	tf.printJobs(summary)
+	tf.printBilling(summary)
+	tf.printBudgets(CheckBudgets(report))
+	tf.printFailures(summary)
+	tf.printHost(report.Host)
+}
+
+// printHost adds a table with the GitHub Enterprise Server host the usage came from, if it wasn't github.com
+func (tf tsvFormatter) printHost(host string) {
+	if host == "" {
+		return
+	}
+	_, _ = fmt.Fprintf(tf.w, "\nHost\n%s\n", host)
+}
+
+// printHidden adds a (hidden) row with the usage of the repository's workflows that the report's filter or top left
+// out, so that the rows still add up to the totals
+func (tf tsvFormatter) printHidden(summary usageSummary, repo repoSummary, runners []string) {
+	hiddenRunners := make(runnerUsage)
+	var hiddenJobTime jobTime
+	var hiddenCost float64
+	for _, workflow := range repo.HiddenWorkflows {
+		hiddenRunners.add(workflow.Runners)
+		hiddenJobTime.add(workflow.JobTime)
+		hiddenCost += workflow.Cost
+	}
+	_, _ = fmt.Fprintf(tf.w, "%s\t(hidden)\t%d", repo.Repo.FullName, repo.Hidden.Usage)
+	for _, env := range runners {
+		_, _ = fmt.Fprintf(tf.w, "\t%d", hiddenRunners[env])
+	}
+	_, _ = fmt.Fprintf(tf.w, "%s%s\n", tsvJobTime(summary, hiddenJobTime), tsvCost(summary, hiddenCost))
+}
+
+// printLeaderboard ranks the usage by workflow, repository or owner, with each one's share of the total as a percentage
+func (tf tsvFormatter) printLeaderboard(board leaderboard) {
+	summary := board.Summary
+	costColumn := ""
+	if summary.HasCost {
+		costColumn = "\tCost"
+	}
+	switch board.GroupBy {
+	case GroupByOwner:
+		_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\t%s\t%s\t%s%s\n", "Rank", "Owner", "Repositories", "Workflows", "Milliseconds", "Share", costColumn)
+	case GroupByRepo:
+		_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\t%s\t%s%s\n", "Rank", "Repo", "Workflows", "Milliseconds", "Share", costColumn)
+	default:
+		_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\t%s\t%s\t%s%s\n", "Rank", "Repo", "Workflow", "Path", "Milliseconds", "Share", costColumn)
+	}
+	for _, entry := range board.Entries {
+		switch board.GroupBy {
+		case GroupByOwner:
+			_, _ = fmt.Fprintf(tf.w, "%d\t%s\t%d\t%d", entry.Rank, entry.Owner, entry.RepoCount, entry.WorkflowCount)
+		case GroupByRepo:
+			_, _ = fmt.Fprintf(tf.w, "%d\t%s\t%d", entry.Rank, entry.Repo, entry.WorkflowCount)
+		default:
+			_, _ = fmt.Fprintf(tf.w, "%d\t%s\t%s\t%s", entry.Rank, entry.Repo, entry.Workflow.Name, entry.Workflow.Path)
+		}
+		_, _ = fmt.Fprintf(tf.w, "\t%d\t%.1f%s\n", entry.Usage, entry.Share, tsvCost(summary, entry.Cost))
+	}
+}
+
+// printBudgets adds a table with the usage of each budget, if there are any
+func (tf tsvFormatter) printBudgets(budgets []budget.Status) {
+	if len(budgets) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(tf.w, "\n%s\t%s\t%s\t%s\t%s\t%s\n", "Budget", "Unit", "Limit", "Used", "Percent", "State")
+	for _, status := range budgets {
+		_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%.2f\t%.2f\t%.1f\t%s\n", budgetName(status), status.Limit.Unit, status.Limit.Amount, status.Used, status.Percent(), status.State)
+	}
+}
+
+// printRuns adds a table of the workflow runs, if they were collected
+func (tf tsvFormatter) printRuns(summary usageSummary) {
+	header := false
+	for _, repo := range summary.Repos {
+		for _, workflow := range repo.Workflows {
+			for _, run := range workflow.Runs {
+				if !header {
+					costColumn := ""
+					if summary.HasCost {
+						costColumn = "\tCost"
+					}
+					_, _ = fmt.Fprintf(tf.w, "\n%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s%s\n", "Repo", "Workflow", "Run", "Event", "Branch", "Actor", "Conclusion", "Created", "Duration", "Milliseconds", costColumn)
+					header = true
+				}
+				_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d%s\n", repo.Repo.FullName, workflow.Workflow.Path, run.Run.Number, run.Run.Event, run.Run.Branch,
+					run.Run.Actor, runOutcome(run.Run), run.Run.CreatedAt.UTC().Format(time.RFC3339), run.Duration, run.Usage, tsvCost(summary, run.Cost))
+			}
+		}
+	}
+}
+
+// printJobs adds a table of the time used by each job in each workflow, if the jobs were collected
+func (tf tsvFormatter) printJobs(summary usageSummary) {
+	header := false
+	for _, repo := range summary.Repos {
+		for _, workflow := range repo.Workflows {
+			for _, job := range workflow.Jobs {
+				if !header {
+					_, _ = fmt.Fprintf(tf.w, "\n%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "Repo", "Workflow", "Job", "Labels", "Runner Group", "Runs", "Milliseconds")
+					header = true
+				}
+				_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\n", repo.Repo.FullName, workflow.Workflow.Path, job.Name, strings.Join(job.Labels, ","), job.RunnerGroup, job.Count, job.Usage)
+			}
+		}
+	}
+}
+
+// printBilling adds a table comparing each owner's billing summary with the usage in the report, if there are any
+func (tf tsvFormatter) printBilling(summary usageSummary) {
+	if !summary.HasBilling {
+		return
+	}
+	_, _ = fmt.Fprintf(tf.w, "\n%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "Owner", "Repositories", "Workflows", "Milliseconds", "Minutes Used", "Included Minutes", "Paid Minutes")
+	for _, owner := range summary.Owners {
+		if owner.Billing == nil {
+			continue
+		}
+		_, _ = fmt.Fprintf(tf.w, "%s\t%d\t%d\t%d\t%d\t%d\t%g\n", owner.Owner, owner.RepoCount, owner.WorkflowCount, owner.Total,
+			owner.Billing.TotalMinutesUsed, owner.Billing.IncludedMinutes, owner.Billing.TotalPaidMinutesUsed)
+	}
+}
+
+// printFailures adds a table for the repositories and workflows that couldn't be collected, and another for the
+// owners whose billing couldn't be collected, if there are any
+func (tf tsvFormatter) printFailures(summary usageSummary) {
+	var repos, owners []client.UsageError
+	for _, failure := range summary.Failures {
+		if failure.Repository == nil && failure.Owner != nil {
+			owners = append(owners, failure)
 		} else {
-			for _, workflow := range workflows {
-				_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%d\n", repoFullName(repo), workflow.Workflow.Path, workflow.Usage)
+			repos = append(repos, failure)
+		}
+	}
+	if len(repos) > 0 {
+		_, _ = fmt.Fprintf(tf.w, "\n%s\t%s\t%s\n", "Repo", "Workflow", "Error")
+		for _, failure := range repos {
+			workflow := "n/a"
+			if failure.Workflow != nil {
+				workflow = failure.Workflow.Path
+			}
+			_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\n", repoFullName(failure.Repository), workflow, failure.Reason())
+		}
+	}
+	if len(owners) > 0 {
+		_, _ = fmt.Fprintf(tf.w, "\n%s\t%s\n", "Owner", "Error")
+		for _, failure := range owners {
+			_, _ = fmt.Fprintf(tf.w, "%s\t%s\n", failure.Owner.Login, failure.Reason())
+		}
+	}
+}
+
+func (tf tsvFormatter) PrintHistory(snapshots []history.Snapshot) {
+	summary := summarizeHistory(snapshots)
+	_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\t%s\n", "Time", "Repo", "Workflow", "Milliseconds")
+	for _, repo := range summary.Repos {
+		if len(repo.Workflows) == 0 {
+			for _, point := range repo.Points {
+				if point.Present {
+					_, _ = fmt.Fprintf(tf.w, "%s\t%s\tn/a\t0\n", point.Time.UTC().Format(time.RFC3339), repo.FullName)
+				}
+			}
+			continue
+		}
+		for _, workflow := range repo.Workflows {
+			for _, point := range workflow.Points {
+				if point.Present {
+					_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\t%d\n", point.Time.UTC().Format(time.RFC3339), repo.FullName, workflow.Workflow.Path, point.Usage)
+				}
 			}
 		}
 	}
 }
 
-func sortedRepositories(usage client.RepoUsage) []*client.Repository {
-	repos := make([]*client.Repository, 0, len(usage))
-	for repo := range usage {
-		repos = append(repos, repo)
+// runnerColumns returns the standard runner environments followed by any others found in the usage
+func runnerColumns(usage client.RepoUsage) []string {
+	seen := make(map[string]bool)
+	for _, env := range standardRunners {
+		seen[env] = true
+	}
+	var extra []string
+	for _, flowUsage := range usage {
+		for _, u := range flowUsage {
+			for env := range u.RunnerMs() {
+				if !seen[env] {
+					seen[env] = true
+					extra = append(extra, env)
+				}
+			}
+		}
+	}
+	sort.Strings(extra)
+	return append(append([]string{}, standardRunners...), extra...)
+}
+
+// tsvCost returns the cost column for a row, or nothing if the summary has no cost estimates
+func tsvCost(summary usageSummary, cost float64) string {
+	if !summary.HasCost {
+		return ""
 	}
-	sort.Slice(repos, func(i, j int) bool {
-		return repoFullName(repos[i]) < repoFullName(repos[j])
-	})
-	return repos
+	return fmt.Sprintf("\t%.2f", cost)
 }
 
-func repoFullName(repo *client.Repository) string {
-	if repo == nil {
+// tsvJobTime formats the self-hosted and GitHub-hosted runner time columns, or nothing if they weren't requested
+func tsvJobTime(summary usageSummary, time jobTime) string {
+	if !summary.HasSelfHosted {
 		return ""
 	}
-	return repo.FullName
+	return fmt.Sprintf("\t%d\t%d", time.SelfHosted, time.Hosted)
+}
+
+func (tf tsvFormatter) PrintDiff(diff Diff) {
+	summary := summarizeDiff(diff)
+	_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", "Repo", "Workflow", "Status", "Before", "After", "Change", "Percent")
+	for _, repo := range summary.Repos {
+		if len(repo.Workflows) == 0 {
+			_, _ = fmt.Fprintf(tf.w, "%s\tn/a\t%s\n", repo.FullName, tsvChange(repo.usageChange))
+			continue
+		}
+		for _, workflow := range repo.Workflows {
+			_, _ = fmt.Fprintf(tf.w, "%s\t%s\t%s\n", repo.FullName, workflow.Workflow.Path, tsvChange(workflow.usageChange))
+		}
+	}
+}
+
+// tsvChange formats the status, usage and change columns; the percentage is left empty if there was no usage before
+func tsvChange(change usageChange) string {
+	pct, ok := change.Percent()
+	percentage := ""
+	if ok {
+		percentage = fmt.Sprintf("%.1f", pct)
+	}
+	return fmt.Sprintf("%s\t%d\t%d\t%d\t%s", change.Status, change.Before, change.After, change.Delta(), percentage)
 }
@@ -2,10 +2,14 @@ package format
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
 	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/cost"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTsvFormatter(t *testing.T) {
@@ -15,17 +19,35 @@ func TestTsvFormatter(t *testing.T) {
 
 	wf := client.Workflow{Name: "Security", Path: ".github/workflows/DevSecOps.yaml", State: "alert"}
 	wfu := make(client.WorkflowUsage)
-	wfu[wf] = 2500
+	wfu[wf] = billable(map[string]uint{"UBUNTU": 2000, "UBUNTU_ARM": 500})
 	r := client.Repository{FullName: "codiform/gh-actions-usage"}
 	ru := make(client.RepoUsage)
 	ru[&r] = wfu
 
 	// When
-	formatter.PrintUsage(ru)
+	formatter.PrintUsage(Report{Usage: ru})
 
 	// Then
-	assert.Equal(t, `Repo	Workflow	Milliseconds
-codiform/gh-actions-usage	.github/workflows/DevSecOps.yaml	2500
+	assert.Equal(t, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS	UBUNTU_ARM
+codiform/gh-actions-usage	.github/workflows/DevSecOps.yaml	2500	0	2000	0	500
+`, output.String())
+}
+
+func TestTsvFormatter_Host(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+	r := client.Repository{FullName: "platform/deployer"}
+
+	// When
+	formatter.PrintUsage(Report{Usage: client.RepoUsage{&r: {}}, Host: "github.example.com"})
+
+	// Then
+	assert.Equal(t, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS
+platform/deployer	n/a	0	0	0	0
+
+Host
+github.example.com
 `, output.String())
 }
 
@@ -40,11 +62,11 @@ func TestTsvFormatter_Empty(t *testing.T) {
 	ru[&r] = wfu
 
 	// When
-	formatter.PrintUsage(ru)
+	formatter.PrintUsage(Report{Usage: ru})
 
 	// Then
-	assert.Equal(t, `Repo	Workflow	Milliseconds
-kim0/salt-states	n/a	0
+	assert.Equal(t, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS
+kim0/salt-states	n/a	0	0	0	0
 `, output.String())
 }
 
@@ -55,13 +77,177 @@ func TestTsvFormatter_MultipleRepositories(t *testing.T) {
 	ru := sampleMultipleRepositoriesUsage()
 
 	// When
-	formatter.PrintUsage(ru)
+	formatter.PrintUsage(Report{Usage: ru})
+
+	// Then
+	assert.Equal(t, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS
+codiform/gh-actions-usage	.github/workflows/ci.yml	500	0	500	0
+codiform/gh-actions-usage	.github/workflows/release.yml	1500	500	1000	0
+codiform/terraform-tools	.github/workflows/ci.yml	1000	0	0	1000
+geoffreywiseman/gh-actuse	n/a	0	0	0	0
+`, output.String())
+}
+
+func TestTsvFormatter_HiddenWorkflows(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, Workflows: WorkflowFilter{Paths: []string{"release.yml"}}})
+
+	// Then
+	assert.Equal(t, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS
+codiform/gh-actions-usage	.github/workflows/release.yml	1500	500	1000	0
+codiform/gh-actions-usage	(hidden)	500	0	500	0
+codiform/terraform-tools	(hidden)	1000	0	0	1000
+geoffreywiseman/gh-actuse	n/a	0	0	0	0
+`, output.String())
+}
+
+func TestTsvFormatter_Cost(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, Cost: cost.Default()})
+
+	// Then
+	assert.Equal(t, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS	Cost
+codiform/gh-actions-usage	.github/workflows/ci.yml	500	0	500	0	0.01
+codiform/gh-actions-usage	.github/workflows/release.yml	1500	500	1000	0	0.09
+codiform/terraform-tools	.github/workflows/ci.yml	1000	0	0	1000	0.02
+geoffreywiseman/gh-actuse	n/a	0	0	0	0	0.00
+`, output.String())
+}
+
+func TestTsvFormatter_History(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+
+	// When
+	formatter.PrintHistory(sampleHistory())
+
+	// Then
+	assert.Equal(t, `Time	Repo	Workflow	Milliseconds
+2026-09-01T12:00:00Z	codiform/gh-actions-usage	.github/workflows/ci.yml	60000
+2026-10-01T12:00:00Z	codiform/gh-actions-usage	.github/workflows/ci.yml	90000
+2026-10-01T12:00:00Z	codiform/gh-actions-usage	.github/workflows/release.yml	30000
+`, output.String())
+}
+
+func TestTsvFormatter_Diff(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+
+	// When
+	formatter.PrintDiff(sampleDiff())
+
+	// Then
+	assert.Equal(t, `Repo	Workflow	Status	Before	After	Change	Percent
+codiform/gh-actions-usage	.github/workflows/ci.yml	changed	60000	90000	30000	50.0
+codiform/gh-actions-usage	.github/workflows/old.yml	removed	10000	0	-10000	-100.0
+codiform/gh-actions-usage	.github/workflows/release.yml	new	0	30000	30000	
+codiform/terraform-tools	.github/workflows/ci.yml	removed	1000	0	-1000	-100.0
+`, output.String())
+}
+
+func TestTsvFormatter_Runs(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+
+	// When
+	formatter.PrintUsage(Report{Usage: sampleRunsUsage(), Runs: true, Cost: cost.Default()})
+
+	// Then
+	assert.Equal(t, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS	Cost
+codiform/gh-actions-usage	.github/workflows/ci.yml	150000	0	150000	0	0.02
+codiform/gh-actions-usage	.github/workflows/deploy.yml	0	0	0	0	0.00
+
+Repo	Workflow	Run	Event	Branch	Actor	Conclusion	Created	Duration	Milliseconds	Cost
+codiform/gh-actions-usage	.github/workflows/ci.yml	1	push	main	geoffreywiseman	success	2026-10-01T09:00:00Z	90000	120000	0.02
+codiform/gh-actions-usage	.github/workflows/ci.yml	2	pull_request	feature	octocat	in_progress	2026-10-02T09:00:00Z	30000	30000	0.01
+codiform/gh-actions-usage	.github/workflows/deploy.yml	1	push	main	octocat	success	2026-10-03T09:00:00Z	300000	0	0.00
+
+Repo	Workflow	Job	Labels	Runner Group	Runs	Milliseconds
+codiform/gh-actions-usage	.github/workflows/ci.yml	build	ubuntu-latest	GitHub Actions	2	130000
+codiform/gh-actions-usage	.github/workflows/ci.yml	lint	ubuntu-latest	GitHub Actions	1	20000
+codiform/gh-actions-usage	.github/workflows/deploy.yml	deploy	self-hosted,linux	Fleet	1	300000
+`, output.String())
+}
+
+func TestTsvFormatter_Jobs(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+
+	// When
+	formatter.printJobs(summarizeUsage(Report{Usage: sampleRunsUsage()}))
+
+	// Then
+	assert.Equal(t, `
+Repo	Workflow	Job	Labels	Runner Group	Runs	Milliseconds
+codiform/gh-actions-usage	.github/workflows/ci.yml	build	ubuntu-latest	GitHub Actions	2	130000
+codiform/gh-actions-usage	.github/workflows/ci.yml	lint	ubuntu-latest	GitHub Actions	1	20000
+codiform/gh-actions-usage	.github/workflows/deploy.yml	deploy	self-hosted,linux	Fleet	1	300000
+`, output.String())
+}
+
+func TestTsvFormatter_SelfHosted(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+
+	// When
+	formatter.PrintUsage(Report{Usage: sampleRunsUsage(), SelfHosted: true})
+
+	// Then
+	lines := strings.Split(output.String(), "\n")
+	assert.Equal(t, "Repo\tWorkflow\tMilliseconds\tMACOS\tUBUNTU\tWINDOWS\tSelf-Hosted\tGitHub-Hosted", lines[0])
+	assert.Equal(t, "codiform/gh-actions-usage\t.github/workflows/ci.yml\t150000\t0\t150000\t0\t0\t141000", lines[1])
+	assert.Equal(t, "codiform/gh-actions-usage\t.github/workflows/deploy.yml\t0\t0\t0\t0\t300000\t0", lines[2])
+}
+
+func TestTsvFormatter_GroupByOwner(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+	ru := sampleMultipleRepositoriesUsage()
+
+	// When
+	formatter.PrintUsage(Report{Usage: ru, GroupBy: GroupByOwner})
 
 	// Then
-	assert.Equal(t, `Repo	Workflow	Milliseconds
-codiform/gh-actions-usage	.github/workflows/ci.yml	500
-codiform/gh-actions-usage	.github/workflows/release.yml	1500
-codiform/terraform-tools	.github/workflows/ci.yml	1000
-geoffreywiseman/gh-actuse	n/a	0
+	assert.Equal(t, `Rank	Owner	Repositories	Workflows	Milliseconds	Share
+1	codiform	2	3	3000	100.0
+2	geoffreywiseman	1	0	0	0.0
+`, output.String())
+}
+
+func TestTsvFormatter_Budgets(t *testing.T) {
+	// Given
+	var output bytes.Buffer
+	formatter := tsvFormatter{&output}
+	budgets := budget.New()
+	require.NoError(t, budgets.Set("codiform/gh-actions-usage=1"))
+
+	// When
+	formatter.PrintUsage(Report{Usage: sampleMultipleRepositoriesUsage(), Budgets: budgets})
+
+	// Then
+	assert.Equal(t, `Repo	Workflow	Milliseconds	MACOS	UBUNTU	WINDOWS
+codiform/gh-actions-usage	.github/workflows/ci.yml	500	0	500	0
+codiform/gh-actions-usage	.github/workflows/release.yml	1500	500	1000	0
+codiform/terraform-tools	.github/workflows/ci.yml	1000	0	0	1000
+geoffreywiseman/gh-actuse	n/a	0	0	0	0
+
+Budget	Unit	Limit	Used	Percent	State
+codiform/gh-actions-usage	minutes	1.00	12.00	1200.0	exceeded
 `, output.String())
 }
@@ -5,11 +5,28 @@ import (
 	"strings"
 
 	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/cost"
 )
 
 type workflowSummary struct {
 	Workflow client.Workflow
 	Usage    uint
+	Runners  runnerUsage
+	Cost     float64
+	// JobTime is how long the jobs ran, if they were collected for the report
+	JobTime jobTime
+	// Runs are the runs in the billing period, oldest first, if they were collected
+	Runs []runSummary
+	// Jobs are the jobs in those runs by name and runner, most time first, if they were collected
+	Jobs []jobSummary
+}
+
+type runSummary struct {
+	Run      client.Run
+	Duration uint
+	Usage    uint
+	Runners  runnerUsage
+	Cost     float64
 }
 
 type repoSummary struct {
@@ -18,6 +35,55 @@ type repoSummary struct {
 	Private   bool
 	Workflows []workflowSummary
 	Total     uint
+	Runners   runnerUsage
+	Cost      float64
+	JobTime   jobTime
+	// Jobs are the jobs from the workflows that are shown, most time first, if they were collected
+	Jobs []jobSummary
+	// Hidden counts the workflows left out of Workflows by the report's filter or top, which are included in the totals
+	Hidden hiddenWorkflows
+	// HiddenWorkflows are those workflows, for formatters whose output must include all of the usage
+	HiddenWorkflows []workflowSummary
+}
+
+// hiddenWorkflows counts the workflows that a report's filter or top left out, and their usage
+type hiddenWorkflows struct {
+	Count int
+	Usage uint
+}
+
+// workflowCount is the number of workflows in the repository, including those that are hidden
+func (r repoSummary) workflowCount() int {
+	return len(r.Workflows) + r.Hidden.Count
+}
+
+func (h *hiddenWorkflows) add(other hiddenWorkflows) {
+	h.Count += other.Count
+	h.Usage += other.Usage
+}
+
+// jobTime is how long jobs ran, from their start to their end, split by where they ran. Time on self-hosted runners
+// isn't included in the usage; time on GitHub-hosted runners is, but billed per job rather than by wall clock.
+type jobTime struct {
+	SelfHosted uint
+	Hosted     uint
+}
+
+func (t *jobTime) add(other jobTime) {
+	t.SelfHosted += other.SelfHosted
+	t.Hosted += other.Hosted
+}
+
+// jobSummary is the time used by a job (by name and runner) across the runs of a workflow
+type jobSummary struct {
+	// Workflow is the path of the workflow the job is in
+	Workflow    string
+	Name        string
+	Labels      []string
+	RunnerGroup string
+	// Count is the number of times the job ran
+	Count int
+	Usage uint
 }
 
 type ownerSummary struct {
@@ -25,72 +91,180 @@ type ownerSummary struct {
 	RepoCount     int
 	WorkflowCount int
 	Total         uint
+	Runners       runnerUsage
+	Cost          float64
+	JobTime       jobTime
+	Hidden        hiddenWorkflows
+	Billing       *client.Billing
 }
 
 type usageSummary struct {
-	Repos         []repoSummary
+	Repos []repoSummary
+	// OmittedRepos are the repositories left out of Repos by the report's top, which are included in the totals
+	OmittedRepos  []repoSummary
 	Owners        []ownerSummary
 	RepoCount     int
 	WorkflowCount int
 	Total         uint
+	Runners       runnerUsage
+	Cost          float64
+	JobTime       jobTime
+	Hidden        hiddenWorkflows
+	Failures      []client.UsageError
+	// HasBilling is set when any of the owners has a billing summary
+	HasBilling bool
+	// HasCost is set when the report has a cost model, so that formatters know to show the estimates
+	HasCost bool
+	// HasSelfHosted is set when the report asks for the time jobs ran on self-hosted and GitHub-hosted runners
+	HasSelfHosted bool
+}
+
+// runnerUsage is the milliseconds of usage by runner environment (e.g. UBUNTU, MACOS, WINDOWS)
+type runnerUsage map[string]uint
+
+// add accumulates the usage from other into ru
+func (ru runnerUsage) add(other runnerUsage) {
+	for env, ms := range other {
+		ru[env] += ms
+	}
+}
+
+// environments returns the runner environments with non-zero usage, sorted by name
+func (ru runnerUsage) environments() []string {
+	envs := make([]string, 0, len(ru))
+	for env, ms := range ru {
+		if ms > 0 {
+			envs = append(envs, env)
+		}
+	}
+	sort.Strings(envs)
+	return envs
 }
 
 // summarizeUsage builds owner and total rollups for human-readable output.
 // Collection intentionally stays as raw RepoUsage so each formatter can choose
 // how much reorganization it needs without coupling API collection to
-// presentation-specific summary rules.
-func summarizeUsage(usage client.RepoUsage) usageSummary {
+// presentation-specific summary rules. When the report has a cost model,
+// estimated costs are rolled up alongside the usage.
+func summarizeUsage(report Report) usageSummary {
+	usage := report.Usage
 	repos := make([]repoSummary, 0, len(usage))
 	owners := make(map[string]*ownerSummary)
 
 	for repo, flowUsage := range usage {
 		workflows := sortedWorkflowUsage(flowUsage)
 		var repoTotal uint
-		for _, workflow := range workflows {
+		var repoCost float64
+		var repoJobTime jobTime
+		var hidden hiddenWorkflows
+		shown := make([]workflowSummary, 0, len(workflows))
+		var hiddenFlows []workflowSummary
+		repoRunners := make(runnerUsage)
+		for i, workflow := range workflows {
+			if report.Cost != nil {
+				workflows[i].Cost = report.Cost.Cost(flowUsage[workflow.Workflow])
+			}
+			if report.Runs {
+				workflows[i].Runs = summarizeRuns(flowUsage[workflow.Workflow], report.Cost)
+			}
+			workflows[i].Jobs = summarizeJobs(workflow.Workflow.Path, flowUsage[workflow.Workflow])
+			workflows[i].JobTime = jobTimeMs(flowUsage[workflow.Workflow])
+			if report.Workflows.shows(workflow.Workflow, workflow.Usage) {
+				shown = append(shown, workflows[i])
+			} else {
+				hidden.add(hiddenWorkflows{Count: 1, Usage: workflow.Usage})
+				hiddenFlows = append(hiddenFlows, workflows[i])
+			}
+			repoJobTime.add(workflows[i].JobTime)
 			repoTotal += workflow.Usage
+			repoCost += workflows[i].Cost
+			repoRunners.add(workflow.Runners)
 		}
 
-		owner := ownerName(repo)
 		repos = append(repos, repoSummary{
 			Repo:      repo,
-			Owner:     owner,
+			Owner:     ownerName(repo),
 			Private:   repo.Private,
-			Workflows: workflows,
+			Workflows: shown,
 			Total:     repoTotal,
+			Runners:   repoRunners,
+			Cost:      repoCost,
+			JobTime:   repoJobTime,
+			Jobs:      workflowJobs(shown),
+			Hidden:    hidden,
+
+			HiddenWorkflows: hiddenFlows,
 		})
+	}
 
-		summary := owners[owner]
+	report.Order.sortRepos(repos)
+	report.Order.hideBeyondTopRepos(repos)
+	report.Order.hideBeyondTop(repos)
+	for _, repo := range repos {
+		summary := owners[repo.Owner]
 		if summary == nil {
-			summary = &ownerSummary{Owner: owner}
-			owners[owner] = summary
+			summary = &ownerSummary{Owner: repo.Owner, Runners: make(runnerUsage)}
+			owners[repo.Owner] = summary
 		}
 		summary.RepoCount++
-		summary.WorkflowCount += len(workflows)
-		summary.Total += repoTotal
+		summary.WorkflowCount += repo.workflowCount()
+		summary.Total += repo.Total
+		summary.Runners.add(repo.Runners)
+		summary.Cost += repo.Cost
+		summary.JobTime.add(repo.JobTime)
+		summary.Hidden.add(repo.Hidden)
 	}
 
-	sort.Slice(repos, func(i, j int) bool {
-		return repos[i].Repo.FullName < repos[j].Repo.FullName
-	})
+	for login, billing := range report.Billing {
+		summary := owners[login]
+		if summary == nil {
+			summary = &ownerSummary{Owner: login, Runners: make(runnerUsage)}
+			owners[login] = summary
+		}
+		summary.Billing = billing
+	}
 
 	ownerTotals := make([]ownerSummary, 0, len(owners))
 	var workflowCount int
 	var total uint
+	var totalCost float64
+	var totalJobTime jobTime
+	var totalHidden hiddenWorkflows
+	runners := make(runnerUsage)
 	for _, owner := range owners {
 		ownerTotals = append(ownerTotals, *owner)
 		workflowCount += owner.WorkflowCount
 		total += owner.Total
+		totalCost += owner.Cost
+		totalJobTime.add(owner.JobTime)
+		totalHidden.add(owner.Hidden)
+		runners.add(owner.Runners)
 	}
 	sort.Slice(ownerTotals, func(i, j int) bool {
 		return ownerTotals[i].Owner < ownerTotals[j].Owner
 	})
 
+	failures := append([]client.UsageError{}, report.Failures...)
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].Subject() < failures[j].Subject()
+	})
+
+	shown, omitted := report.Order.topRepos(repos)
 	return usageSummary{
-		Repos:         repos,
+		Repos:         shown,
+		OmittedRepos:  omitted,
 		Owners:        ownerTotals,
 		RepoCount:     len(repos),
 		WorkflowCount: workflowCount,
 		Total:         total,
+		Runners:       runners,
+		Cost:          totalCost,
+		JobTime:       totalJobTime,
+		Hidden:        totalHidden,
+		Failures:      failures,
+		HasBilling:    len(report.Billing) > 0,
+		HasCost:       report.Cost != nil,
+		HasSelfHosted: report.SelfHosted,
 	}
 }
 
@@ -99,19 +273,127 @@ func sortedWorkflowUsage(flowUsage client.WorkflowUsage) []workflowSummary {
 	for workflow, workflowUsage := range flowUsage {
 		workflows = append(workflows, workflowSummary{
 			Workflow: workflow,
-			Usage:    workflowUsage,
+			Usage:    workflowUsage.TotalMs(),
+			Runners:  workflowUsage.RunnerMs(),
 		})
 	}
 	sort.Slice(workflows, func(i, j int) bool {
-		if workflows[i].Workflow.Path != workflows[j].Workflow.Path {
-			return workflows[i].Workflow.Path < workflows[j].Workflow.Path
+		return workflowLess(workflows[i].Workflow, workflows[j].Workflow)
+	})
+	return workflows
+}
+
+// summarizeRuns summarizes the runs of a workflow, if they were collected, oldest first
+func summarizeRuns(usage *client.Usage, model *cost.Model) []runSummary {
+	if usage == nil || len(usage.Runs) == 0 {
+		return nil
+	}
+	runs := make([]runSummary, 0, len(usage.Runs))
+	for _, run := range usage.Runs {
+		summary := runSummary{Run: run.Run, Duration: run.DurationMs, Usage: run.Usage.TotalMs(), Runners: run.Usage.RunnerMs()}
+		if model != nil {
+			summary.Cost = model.Cost(run.Usage)
 		}
-		if workflows[i].Workflow.Name != workflows[j].Workflow.Name {
-			return workflows[i].Workflow.Name < workflows[j].Workflow.Name
+		runs = append(runs, summary)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].Run.CreatedAt.Equal(runs[j].Run.CreatedAt) {
+			return runs[i].Run.CreatedAt.Before(runs[j].Run.CreatedAt)
 		}
-		return workflows[i].Workflow.ID < workflows[j].Workflow.ID
+		return runs[i].Run.Number < runs[j].Run.Number
 	})
-	return workflows
+	return runs
+}
+
+// summarizeJobs totals the time of each job across the runs of a workflow, by job name and runner, if the jobs
+// were collected
+func summarizeJobs(workflow string, usage *client.Usage) []jobSummary {
+	if usage == nil {
+		return nil
+	}
+	type jobKey struct {
+		name, labels, group string
+	}
+	totals := make(map[jobKey]*jobSummary)
+	var keys []jobKey
+	for _, run := range usage.Runs {
+		for _, job := range run.Jobs {
+			key := jobKey{name: job.Name, labels: strings.Join(job.Labels, ","), group: job.RunnerGroup}
+			total := totals[key]
+			if total == nil {
+				total = &jobSummary{Workflow: workflow, Name: job.Name, Labels: job.Labels, RunnerGroup: job.RunnerGroup}
+				totals[key] = total
+				keys = append(keys, key)
+			}
+			total.Count++
+			total.Usage += run.JobMs(job)
+		}
+	}
+	jobs := make([]jobSummary, 0, len(keys))
+	for _, key := range keys {
+		jobs = append(jobs, *totals[key])
+	}
+	return sortJobs(jobs)
+}
+
+// workflowJobs combines the jobs of the workflows, most time first
+func workflowJobs(workflows []workflowSummary) []jobSummary {
+	var jobs []jobSummary
+	for _, workflow := range workflows {
+		jobs = append(jobs, workflow.Jobs...)
+	}
+	return sortJobs(jobs)
+}
+
+// jobTimeMs totals how long the jobs in every attempt of the workflow's runs ran on self-hosted and GitHub-hosted
+// runners, if the jobs were collected
+func jobTimeMs(usage *client.Usage) jobTime {
+	var total jobTime
+	if usage == nil {
+		return total
+	}
+	for _, run := range usage.Runs {
+		for _, job := range run.Jobs {
+			if job.SelfHosted() {
+				total.SelfHosted += job.DurationMs()
+			} else {
+				total.Hosted += job.DurationMs()
+			}
+		}
+	}
+	return total
+}
+
+// sortJobs orders jobs by the most time first, then by workflow, name and labels
+func sortJobs(jobs []jobSummary) []jobSummary {
+	if len(jobs) == 0 {
+		return nil
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		a, b := jobs[i], jobs[j]
+		switch {
+		case a.Usage != b.Usage:
+			return a.Usage > b.Usage
+		case a.Workflow != b.Workflow:
+			return a.Workflow < b.Workflow
+		case a.Name != b.Name:
+			return a.Name < b.Name
+		default:
+			return strings.Join(a.Labels, ",") < strings.Join(b.Labels, ",")
+		}
+	})
+	return jobs
+}
+
+// workflowLess orders workflows by path, then name and ID
+func workflowLess(a, b client.Workflow) bool {
+	if a.Path != b.Path {
+		return a.Path < b.Path
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.ID < b.ID
 }
 
 func ownerName(repo *client.Repository) string {
@@ -127,3 +409,10 @@ func ownerName(repo *client.Repository) string {
 	}
 	return ""
 }
+
+func repoFullName(repo *client.Repository) string {
+	if repo == nil {
+		return ""
+	}
+	return repo.FullName
+}
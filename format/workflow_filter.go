@@ -0,0 +1,55 @@
+package format
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// WorkflowFilter decides which workflows are shown in a report. Workflows that are hidden still count towards
+// the repository, owner and overall totals, which say how many were hidden.
+type WorkflowFilter struct {
+	// States are the workflow states to show (e.g. active, disabled_manually); empty shows every state
+	States []string
+	// Paths are globs for the workflow paths to show, matched against the file name unless they contain a
+	// slash (e.g. deploy-* or .github/workflows/*.yml); empty shows every path
+	Paths []string
+	// MinUsage is the fewest milliseconds of usage a workflow needs to be shown
+	MinUsage uint
+}
+
+// Validate checks that the path patterns are valid globs
+func (f WorkflowFilter) Validate() error {
+	for _, pattern := range f.Paths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid workflow path pattern %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// shows returns true if the workflow with the specified usage should be shown
+func (f WorkflowFilter) shows(workflow client.Workflow, usage uint) bool {
+	if usage < f.MinUsage {
+		return false
+	}
+	if len(f.States) > 0 && !slices.ContainsFunc(f.States, func(state string) bool { return strings.EqualFold(state, workflow.State) }) {
+		return false
+	}
+	if len(f.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range f.Paths {
+		name := path.Base(workflow.Path)
+		if strings.Contains(pattern, "/") {
+			name = workflow.Path
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
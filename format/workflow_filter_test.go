@@ -0,0 +1,42 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowFilter_Shows(t *testing.T) {
+	ci := client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}
+	deploy := client.Workflow{Name: "Deploy", Path: ".github/workflows/deploy-prod.yml", State: "disabled_manually"}
+
+	tests := []struct {
+		name     string
+		filter   WorkflowFilter
+		workflow client.Workflow
+		usage    uint
+		expected bool
+	}{
+		{"empty filter", WorkflowFilter{}, ci, 0, true},
+		{"state", WorkflowFilter{States: []string{"active"}}, ci, 0, true},
+		{"other state", WorkflowFilter{States: []string{"active"}}, deploy, 0, false},
+		{"state ignores case", WorkflowFilter{States: []string{"DISABLED_MANUALLY"}}, deploy, 0, true},
+		{"file name glob", WorkflowFilter{Paths: []string{"deploy-*"}}, deploy, 0, true},
+		{"file name glob mismatch", WorkflowFilter{Paths: []string{"deploy-*"}}, ci, 0, false},
+		{"path glob", WorkflowFilter{Paths: []string{".github/workflows/*.yml"}}, ci, 0, true},
+		{"any path glob", WorkflowFilter{Paths: []string{"release.yml", "ci.yml"}}, ci, 0, true},
+		{"enough usage", WorkflowFilter{MinUsage: 300000}, ci, 300000, true},
+		{"too little usage", WorkflowFilter{MinUsage: 300000}, ci, 299999, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.filter.shows(test.workflow, test.usage))
+		})
+	}
+}
+
+func TestWorkflowFilter_Validate(t *testing.T) {
+	assert.NoError(t, WorkflowFilter{Paths: []string{"deploy-*"}}.Validate())
+	assert.Error(t, WorkflowFilter{Paths: []string{"deploy-["}}.Validate())
+}
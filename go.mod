@@ -8,6 +8,7 @@ require (
 	github.com/cli/go-gh v1.2.1
 	github.com/stretchr/testify v1.11.1
 	golang.org/x/net v0.31.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -26,5 +27,4 @@ require (
 	github.com/thlib/go-timezone-local v0.0.3 // indirect
 	golang.org/x/sys v0.27.0 // indirect
 	golang.org/x/term v0.26.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,206 @@
+// Package history stores snapshots of usage, since the API only reports on the current billing period.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/pkg/config"
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+const (
+	dirPermissions  = 0o700
+	filePermissions = 0o600
+	// maxLineSize allows for snapshots of large organizations, which are stored on a single line
+	maxLineSize = 64 * 1024 * 1024
+)
+
+// Snapshot is the usage collected by one run of the extension
+type Snapshot struct {
+	Time         time.Time    `json:"time"`
+	Repositories []Repository `json:"repositories"`
+	// Host is the GitHub Enterprise Server host the usage came from, or empty for github.com
+	Host string `json:"host,omitempty"`
+}
+
+// Repository is the usage of a repository within a Snapshot
+type Repository struct {
+	FullName  string     `json:"fullName"`
+	Name      string     `json:"name"`
+	ID        uint       `json:"id"`
+	Owner     string     `json:"owner"`
+	OwnerType string     `json:"ownerType,omitempty"`
+	Private   bool       `json:"private"`
+	Workflows []Workflow `json:"workflows"`
+}
+
+// Workflow is the usage of a workflow within a Snapshot, in milliseconds by runner environment
+type Workflow struct {
+	ID       uint            `json:"id"`
+	Name     string          `json:"name"`
+	Path     string          `json:"path"`
+	State    string          `json:"state"`
+	Billable map[string]uint `json:"billable"`
+}
+
+// NewSnapshot captures the usage at the specified time
+func NewSnapshot(at time.Time, usage client.RepoUsage) Snapshot {
+	snapshot := Snapshot{Time: at.UTC(), Repositories: make([]Repository, 0, len(usage))}
+	for repo, flowUsage := range usage {
+		item := Repository{FullName: repo.FullName, Name: repo.Name, ID: repo.ID, Private: repo.Private, Workflows: make([]Workflow, 0, len(flowUsage))}
+		if repo.Owner != nil {
+			item.Owner = repo.Owner.Login
+			item.OwnerType = repo.Owner.Type
+		} else {
+			item.Owner, _, _ = strings.Cut(repo.FullName, "/")
+		}
+		for flow, u := range flowUsage {
+			item.Workflows = append(item.Workflows, Workflow{ID: flow.ID, Name: flow.Name, Path: flow.Path, State: flow.State, Billable: u.RunnerMs()})
+		}
+		sort.Slice(item.Workflows, func(i, j int) bool {
+			if item.Workflows[i].Path != item.Workflows[j].Path {
+				return item.Workflows[i].Path < item.Workflows[j].Path
+			}
+			return item.Workflows[i].ID < item.Workflows[j].ID
+		})
+		snapshot.Repositories = append(snapshot.Repositories, item)
+	}
+	sort.Slice(snapshot.Repositories, func(i, j int) bool {
+		return snapshot.Repositories[i].FullName < snapshot.Repositories[j].FullName
+	})
+	return snapshot
+}
+
+// RepoUsage restores the usage from the snapshot, so that it can be summarized like the usage from the API
+func (s Snapshot) RepoUsage() client.RepoUsage {
+	usage := make(client.RepoUsage, len(s.Repositories))
+	owners := make(map[string]*client.User)
+	for _, repo := range s.Repositories {
+		owner := owners[repo.Owner]
+		if owner == nil {
+			owner = &client.User{Login: repo.Owner, Type: repo.OwnerType}
+			owners[repo.Owner] = owner
+		}
+		flowUsage := make(client.WorkflowUsage, len(repo.Workflows))
+		for _, flow := range repo.Workflows {
+			billable := make(map[string]*client.UsageDetails, len(flow.Billable))
+			for env, ms := range flow.Billable {
+				billable[env] = &client.UsageDetails{TotalMs: ms}
+			}
+			flowUsage[client.Workflow{ID: flow.ID, Name: flow.Name, Path: flow.Path, State: flow.State}] = &client.Usage{Billable: billable}
+		}
+		usage[&client.Repository{Owner: owner, FullName: repo.FullName, Name: repo.Name, ID: repo.ID, Private: repo.Private}] = flowUsage
+	}
+	return usage
+}
+
+// Select returns the snapshot with only the repositories matching one of the targets, which are either an
+// owner (e.g. codiform) or a repository (e.g. codiform/gh-actions-usage); with no targets, everything matches
+func (s Snapshot) Select(targets []string) Snapshot {
+	if len(targets) == 0 {
+		return s
+	}
+	selected := Snapshot{Time: s.Time, Repositories: make([]Repository, 0)}
+	for _, repo := range s.Repositories {
+		for _, target := range targets {
+			if strings.EqualFold(target, repo.FullName) || strings.EqualFold(target, repo.Owner) {
+				selected.Repositories = append(selected.Repositories, repo)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// IsHost returns true if the snapshot's usage came from the host, ignoring case
+func (s Snapshot) IsHost(host string) bool {
+	current := s.Host
+	if current == "" {
+		current = client.GitHubHost
+	}
+	return strings.EqualFold(current, host)
+}
+
+// ForHost returns the snapshots with usage from the host, in order, so that the usage of different hosts is never
+// compared
+func ForHost(snapshots []Snapshot, host string) []Snapshot {
+	selected := make([]Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if snapshot.IsHost(host) {
+			selected = append(selected, snapshot)
+		}
+	}
+	return selected
+}
+
+// Store is a file of snapshots, one JSON document per line, oldest first
+type Store struct {
+	Path string
+}
+
+// DefaultStore returns the store in the gh config directory
+func DefaultStore() Store {
+	return Store{Path: filepath.Join(config.ConfigDir(), "actions-usage", "history.jsonl")}
+}
+
+// Append adds a snapshot to the end of the store, creating it if necessary
+func (s Store) Append(snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("could not encode snapshot: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(s.Path), dirPermissions); err != nil {
+		return fmt.Errorf("could not create history directory: %w", err)
+	}
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, filePermissions)
+	if err != nil {
+		return fmt.Errorf("could not open history: %w", err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("could not write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load reads all the snapshots in the store, oldest first; a store that doesn't exist yet has no snapshots
+func (s Store) Load() ([]Snapshot, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []Snapshot{}, nil
+		}
+		return nil, fmt.Errorf("could not open history: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	snapshots := make([]Snapshot, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snapshot); err != nil {
+			return nil, fmt.Errorf("could not read snapshot on line %d of %s: %w", line, s.Path, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read history: %w", err)
+	}
+	return snapshots, nil
+}
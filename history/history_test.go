@@ -0,0 +1,147 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleUsage() client.RepoUsage {
+	codiform := &client.User{Login: "codiform", Type: "Organization"}
+	ci := client.Workflow{ID: 1, Name: "CI", Path: ".github/workflows/ci.yml", State: "active"}
+	release := client.Workflow{ID: 2, Name: "Release", Path: ".github/workflows/release.yml", State: "active"}
+	first := &client.Repository{Owner: codiform, FullName: "codiform/gh-actions-usage", Name: "gh-actions-usage", ID: 10, Private: true}
+	second := &client.Repository{Owner: codiform, FullName: "codiform/terraform-tools", Name: "terraform-tools", ID: 11}
+	third := &client.Repository{FullName: "geoffreywiseman/gh-actuse", Name: "gh-actuse", ID: 12}
+
+	return client.RepoUsage{
+		first: {
+			release: {Billable: map[string]*client.UsageDetails{"MACOS": {TotalMs: 500}}},
+			ci:      {Billable: map[string]*client.UsageDetails{"UBUNTU": {TotalMs: 1000}}},
+		},
+		second: {},
+		third:  {},
+	}
+}
+
+func TestNewSnapshot(t *testing.T) {
+	// Given
+	at := time.Date(2026, 10, 1, 8, 0, 0, 0, time.FixedZone("EDT", -4*60*60))
+
+	// When
+	snapshot := NewSnapshot(at, sampleUsage())
+
+	// Then
+	assert.Equal(t, time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), snapshot.Time)
+	require.Len(t, snapshot.Repositories, 3)
+	first := snapshot.Repositories[0]
+	assert.Equal(t, "codiform/gh-actions-usage", first.FullName)
+	assert.Equal(t, "codiform", first.Owner)
+	assert.Equal(t, "Organization", first.OwnerType)
+	assert.True(t, first.Private)
+	require.Len(t, first.Workflows, 2)
+	assert.Equal(t, ".github/workflows/ci.yml", first.Workflows[0].Path)
+	assert.Equal(t, map[string]uint{"UBUNTU": 1000}, first.Workflows[0].Billable)
+	assert.Equal(t, "geoffreywiseman", snapshot.Repositories[2].Owner)
+}
+
+func TestSnapshot_RepoUsage(t *testing.T) {
+	// Given
+	snapshot := NewSnapshot(time.Now(), sampleUsage())
+
+	// When
+	usage := snapshot.RepoUsage()
+
+	// Then
+	require.Len(t, usage, 3)
+	for repo, flowUsage := range usage {
+		if repo.FullName != "codiform/gh-actions-usage" {
+			assert.Empty(t, flowUsage)
+			continue
+		}
+		assert.Equal(t, "codiform", repo.Owner.Login)
+		assert.Equal(t, uint(10), repo.ID)
+		release := client.Workflow{ID: 2, Name: "Release", Path: ".github/workflows/release.yml", State: "active"}
+		assert.Equal(t, map[string]uint{"MACOS": 500}, flowUsage[release].RunnerMs())
+	}
+}
+
+func TestSnapshot_Select(t *testing.T) {
+	// Given
+	snapshot := NewSnapshot(time.Now(), sampleUsage())
+
+	// When
+	byOwner := snapshot.Select([]string{"Codiform"})
+	byRepo := snapshot.Select([]string{"geoffreywiseman/gh-actuse"})
+	everything := snapshot.Select(nil)
+
+	// Then
+	assert.Len(t, byOwner.Repositories, 2)
+	require.Len(t, byRepo.Repositories, 1)
+	assert.Equal(t, "geoffreywiseman/gh-actuse", byRepo.Repositories[0].FullName)
+	assert.Len(t, everything.Repositories, 3)
+}
+
+func TestForHost(t *testing.T) {
+	// Given
+	public := NewSnapshot(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), sampleUsage())
+	enterprise := NewSnapshot(time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC), sampleUsage())
+	enterprise.Host = "github.example.com"
+	snapshots := []Snapshot{public, enterprise}
+
+	// When
+	fromPublic := ForHost(snapshots, "github.com")
+	fromEnterprise := ForHost(snapshots, "GitHub.Example.com")
+	fromElsewhere := ForHost(snapshots, "github.other.com")
+
+	// Then
+	assert.Equal(t, []Snapshot{public}, fromPublic)
+	assert.Equal(t, []Snapshot{enterprise}, fromEnterprise)
+	assert.Empty(t, fromElsewhere)
+}
+
+func TestStore_AppendAndLoad(t *testing.T) {
+	// Given
+	store := Store{Path: filepath.Join(t.TempDir(), "actions-usage", "history.jsonl")}
+	first := NewSnapshot(time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), sampleUsage())
+	second := NewSnapshot(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), client.RepoUsage{})
+
+	// When
+	require.NoError(t, store.Append(first))
+	require.NoError(t, store.Append(second))
+	snapshots, err := store.Load()
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, []Snapshot{first, second}, snapshots)
+}
+
+func TestStore_Load_Missing(t *testing.T) {
+	// Given
+	store := Store{Path: filepath.Join(t.TempDir(), "history.jsonl")}
+
+	// When
+	snapshots, err := store.Load()
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, snapshots)
+}
+
+func TestStore_Load_Corrupt(t *testing.T) {
+	// Given
+	store := Store{Path: filepath.Join(t.TempDir(), "history.jsonl")}
+	require.NoError(t, os.WriteFile(store.Path, []byte("{\"time\":\"2026-10-01T00:00:00Z\"}\nnot json\n"), 0o600))
+
+	// When
+	snapshots, err := store.Load()
+
+	// Then
+	require.ErrorContains(t, err, "line 2")
+	assert.Nil(t, snapshots)
+}
@@ -2,69 +2,304 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"runtime/debug"
-	"strings"
+	"time"
 
 	gogherrors "github.com/cli/go-gh/pkg/api"
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
 	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/cost"
 	"github.com/geoffreywiseman/gh-actions-usage/format"
+	"github.com/geoffreywiseman/gh-actions-usage/usage"
 )
 
-var gh client.Client
-
 type config struct {
-	format  format.Formatter
-	output  string
-	skip    bool
-	verbose bool
-	w       io.Writer
+	format      format.Formatter
+	output      string
+	skip        bool
+	verbose     bool
+	estimate    bool
+	rates       string
+	cost        *cost.Model
+	snapshot    bool
+	historyFile string
+	hostname    string
+	selfHosted  bool
+	collect     usage.Options
+	workflows   format.WorkflowFilter
+	minUsage    time.Duration
+	sort        string
+	top         int
+	topRepos    int
+	order       format.Order
+	groupBy     string
+	group       format.GroupBy
+	budgets     stringList
+	budgetFile  string
+	budget      *budget.Budgets
+	cacheTTL    time.Duration
+	noCache     bool
+	record      string
+	replay      string
+	timeout     time.Duration
+	// stderr is where errors are written, so that they never mix with the report on stdout
+	stderr io.Writer
+}
+
+// ConflictingOptionsError is an error when options that can't be used together were specified
+type ConflictingOptionsError string
+
+// Error returns a formatted error message for ConflictingOptionsError
+func (e ConflictingOptionsError) Error() string {
+	return "Options can't be used together: " + string(e)
 }
 
-// UnknownRepoError is an error condition when a repository cannot be found
-type UnknownRepoError string
+// InvalidTimeoutError is an error when the timeout is negative
+type InvalidTimeoutError time.Duration
 
-// Error returns a formatted error message for UnknownRepoError
-func (e UnknownRepoError) Error() string {
-	return "Unknown repository: " + string(e)
+// Error returns a formatted error message for InvalidTimeoutError
+func (e InvalidTimeoutError) Error() string {
+	return fmt.Sprintf("Invalid timeout: %s (must not be negative)", time.Duration(e))
 }
 
-// UnknownUserError is an error condition where the user cannot be found
-type UnknownUserError string
+// StoppedError is why collection stopped before it finished, e.g. an interrupt or the timeout
+type StoppedError string
 
-// Error returns a formatted error message for UnknownUserError
-func (e UnknownUserError) Error() string {
-	return "Unknown user: " + string(e)
+// Error returns the reason for StoppedError
+func (e StoppedError) Error() string {
+	return string(e)
 }
 
+// Exit codes, so that scripts can tell a complete report from a partial one
+const (
+	exitOK      = 0
+	exitError   = 1
+	exitPartial = 2
+	// exitOverBudget is when the usage is over one of the budgets, even if the report is partial
+	exitOverBudget = 3
+)
+
 func main() {
-	fmt.Printf("GitHub Actions Usage (%s)\n\n", getVersion())
+	os.Exit(run())
+}
 
-	gh = client.New()
+func run() int {
+	switch command, args := subcommand(os.Args[1:]); command {
+	case "history":
+		return runHistory(args)
+	case "diff":
+		return runDiff(args)
+	}
 
-	cfg := &config{w: os.Stdout}
+	cfg := &config{stderr: os.Stderr}
 	flag.BoolVar(&cfg.skip, "skip", false, "Skips displaying repositories with no workflows")
 	flag.BoolVar(&cfg.verbose, "verbose", false, "Print verbose output including additional error details")
-	flag.StringVar(&cfg.output, "output", "human", "Output format: human or TSV (machine readable)")
+	flag.StringVar(&cfg.output, "output", "human", "Output format: human, TSV or JSON (machine readable)")
+	flag.IntVar(&cfg.collect.Concurrency, "concurrency", usage.DefaultConcurrency, "Maximum number of concurrent API requests (0 uses the default)")
+	flag.BoolVar(&cfg.collect.Billing, "billing", false, "Show the billing summary (included, used and paid minutes) for user and organization targets")
+	flag.BoolVar(&cfg.estimate, "cost", false, "Estimate the cost of usage using GitHub's per-minute rates")
+	flag.StringVar(&cfg.rates, "rates", "", "YAML file of per-minute rates and runner multipliers for cost estimates (implies --cost)")
+	flag.StringVar(&cfg.hostname, "hostname", "", "GitHub host to use, e.g. for GitHub Enterprise Server (default: GH_HOST, the current repository's host, or the gh host)")
+	flag.BoolVar(&cfg.collect.Runs, "runs", false, "Show the usage of each workflow run in the current billing period (one API request per run)")
+	flag.BoolVar(&cfg.collect.Jobs, "jobs", false, "Show the jobs that used the most time in each workflow and repository, by runner labels (collects the runs, but only lists them with --runs)")
+	flag.BoolVar(&cfg.selfHosted, "self-hosted", false, "Show how long jobs ran on self-hosted runners, which isn't billable, and on GitHub-hosted runners (implies --jobs)")
+	flag.BoolVar(&cfg.collect.Filter.ExcludeArchived, "exclude-archived", false, "Leave out archived repositories of user and organization targets")
+	flag.BoolVar(&cfg.collect.Filter.ExcludeForks, "exclude-forks", false, "Leave out forked repositories of user and organization targets")
+	flag.StringVar(&cfg.collect.Filter.Visibility, "visibility", "", "Only include repositories of user and organization targets with this visibility: public, private or internal")
+	flag.Var((*stringList)(&cfg.collect.Filter.Topics), "topic", "Only include repositories of user and organization targets with this topic (can be repeated)")
+	flag.Var((*patternList)(&cfg.collect.Filter.Include), "include", "Only include repositories of user and organization targets matching this glob or /regexp/ (can be repeated)")
+	flag.Var((*patternList)(&cfg.collect.Filter.Exclude), "exclude", "Leave out repositories of user and organization targets matching this glob or /regexp/ (can be repeated)")
+	flag.Var((*stringList)(&cfg.workflows.States), "workflow-state", "Only show workflows in this state, e.g. active or disabled_manually (can be repeated)")
+	flag.Var((*stringList)(&cfg.workflows.Paths), "workflow-path", "Only show workflows whose path matches this glob (can be repeated)")
+	flag.DurationVar(&cfg.minUsage, "min-usage", 0, "Only show workflows that used at least this much time, e.g. 5m")
+	flag.StringVar(&cfg.sort, "sort", "", "Sort repositories and workflows by name, usage or workflows, optionally followed by :asc or :desc (default: name, or usage with --top or --top-repos)")
+	flag.IntVar(&cfg.top, "top", 0, "Only show this many workflows across all repositories, in sort order (0 shows them all)")
+	flag.IntVar(&cfg.topRepos, "top-repos", 0, "Only show this many repositories, in sort order (0 shows them all)")
+	flag.StringVar(&cfg.groupBy, "group-by", "", "Rank the usage by workflow, repo or owner instead of listing it by repository")
+	flag.Var(&cfg.budgets, "budget", "Fail when the usage is over a budget in minutes or dollars, e.g. 3000, $25, codiform=$25 or codiform/api=500 (can be repeated)")
+	flag.StringVar(&cfg.budgetFile, "budget-file", "", "YAML file with budgets for all of the usage, owners and repositories")
+	flag.DurationVar(&cfg.cacheTTL, "cache-ttl", 0, "Use cached API responses younger than this without revalidating them, e.g. 10m (default: always revalidate)")
+	flag.BoolVar(&cfg.noCache, "no-cache", false, "Don't cache API responses between runs")
+	flag.StringVar(&cfg.record, "record", "", "Save every API response in this directory, so that the run can be replayed with --replay")
+	flag.StringVar(&cfg.replay, "replay", "", "Serve API responses from a directory saved with --record instead of connecting to GitHub")
+	flag.DurationVar(&cfg.timeout, "timeout", 0, "Stop collecting after this long and show the usage collected so far, e.g. 5m (default: no timeout)")
+	flag.BoolVar(&cfg.snapshot, "snapshot", false, "Store the usage in the history, for the history command")
+	flag.StringVar(&cfg.historyFile, "history-file", "", "File to store snapshots in (default: history.jsonl in the gh config directory)")
 	flag.Parse()
 
-	var err error
+	settings, err := loadConfig(flag.CommandLine, true)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Configuration: %s\n\n", err)
+		return exitError
+	}
+
+	// JSON output is parsed as a whole, so it can't be preceded by the banner
+	if cfg.output != "json" {
+		fmt.Printf("GitHub Actions Usage (%s)\n\n", getVersion())
+	}
+
 	cfg.format, err = format.GetFormatter(cfg.output)
 	if err != nil {
-		fmt.Printf("Invalid Option: %s\n\n", err)
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
 		printHelp()
-		return
+		return exitError
+	}
+	if err = cfg.collect.Validate(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHelp()
+		return exitError
+	}
+	if cfg.minUsage < 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", InvalidMinUsageError(cfg.minUsage))
+		printHelp()
+		return exitError
+	}
+	cfg.workflows.MinUsage = uint(cfg.minUsage.Milliseconds())
+	if err = cfg.workflows.Validate(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHelp()
+		return exitError
+	}
+	if cfg.order, err = format.ParseOrder(cfg.sort, cfg.top, cfg.topRepos); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHelp()
+		return exitError
+	}
+	if cfg.group, err = format.ParseGroupBy(cfg.groupBy); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHelp()
+		return exitError
+	}
+	if err = checkGroupBy(*cfg); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHelp()
+		return exitError
+	}
+	if cfg.budget, err = loadBudgets(cfg.budgetFile, cfg.budgets); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHelp()
+		return exitError
 	}
+	if cfg.rates != "" {
+		cfg.cost, err = cost.Load(cfg.rates)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+			printHelp()
+			return exitError
+		}
+	} else if cfg.estimate {
+		cfg.cost = cost.Default()
+	}
+	if cfg.timeout < 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", InvalidTimeoutError(cfg.timeout))
+		printHelp()
+		return exitError
+	}
+	if cfg.record != "" && cfg.replay != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", ConflictingOptionsError("--record and --replay"))
+		printHelp()
+		return exitError
+	}
+	if cfg.collect.Targets, err = settings.expandTargets(flag.Args()); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHelp()
+		return exitError
+	}
+	cfg.collect.Jobs = cfg.collect.Jobs || cfg.selfHosted
+	options := client.Options{CacheTTL: cfg.cacheTTL, RecordDir: cfg.record, ReplayDir: cfg.replay}
+	if !cfg.noCache && cfg.replay == "" {
+		options.CacheDir = client.DefaultCacheDir()
+	}
+	gh, err := client.New(clientHost(*cfg), options)
+	if err != nil {
+		printError(*cfg, "Error connecting to GitHub", err)
+		return exitError
+	}
+	cfg.collect.AsOf = gh.RecordedAt
+
+	ctx, stop := stoppableContext(cfg.timeout)
+	defer stop()
+	return displayUsage(ctx, *cfg, &gh)
+}
+
+// checkGroupBy returns a ConflictingOptionsError if --group-by was given with options that don't apply to a
+// leaderboard, which is always ranked by usage and limited by --top alone
+func checkGroupBy(cfg config) error {
+	if cfg.groupBy == "" {
+		return nil
+	}
+	if cfg.sort != "" {
+		return ConflictingOptionsError("--group-by and --sort")
+	}
+	if cfg.topRepos != 0 {
+		return ConflictingOptionsError("--group-by and --top-repos")
+	}
+	return nil
+}
+
+// clientHost returns the host to connect to: the host given with --hostname or GH_HOST if there is one, otherwise,
+// when there are no targets, the host of the current repository, so that it doesn't have to be the gh host.
+// An empty host is the gh host.
+func clientHost(cfg config) string {
+	if cfg.hostname != "" {
+		return cfg.hostname
+	}
+	if os.Getenv("GH_HOST") != "" || len(cfg.collect.Targets) > 0 {
+		return ""
+	}
+	return client.CurrentHost()
+}
+
+// stoppableContext returns a context that is cancelled by an interrupt (Ctrl-C) or, if timeout isn't zero,
+// once the timeout has passed, with a StoppedError as the cause. A second interrupt exits immediately.
+func stoppableContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	stoppable, cancel := context.WithCancelCause(context.Background())
+	watchInterrupts(stoppable, cancel)
+	stop := func() { cancel(nil) }
+	if timeout == 0 {
+		return stoppable, stop
+	}
+	ctx, cancelTimeout := context.WithTimeoutCause(stoppable, timeout, StoppedError(fmt.Sprintf("Timed out after %s", timeout)))
+	return ctx, func() {
+		cancelTimeout()
+		stop()
+	}
+}
 
-	if len(flag.Args()) < 1 {
-		tryDisplayCurrentRepo(*cfg)
-	} else {
-		tryDisplayAllSpecified(*cfg, flag.Args())
+// watchInterrupts cancels ctx when the first interrupt arrives, and stops watching once ctx is done. The
+// returned channel is closed when the watcher has finished.
+func watchInterrupts(ctx context.Context, cancel context.CancelCauseFunc) <-chan struct{} {
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-interrupts:
+			signal.Stop(interrupts)
+			cancel(StoppedError("Interrupted"))
+		case <-ctx.Done():
+			signal.Stop(interrupts)
+		}
+	}()
+	return done
+}
+
+// stoppedCause returns the reason that ctx was stopped in place of err, if it was stopped, since requests
+// that were cancelled only fail with context.Canceled or context.DeadlineExceeded
+func stoppedCause(ctx context.Context, err error) error {
+	var stopped StoppedError
+	if errors.As(context.Cause(ctx), &stopped) {
+		return stopped
 	}
+	return err
 }
 
 func getVersion() string {
@@ -85,82 +320,147 @@ func getVersion() string {
 	return "?"
 }
 
-func tryDisplayCurrentRepo(cfg config) {
-	repo, err := gh.GetCurrentRepository()
-	if repo == nil {
-		if err != nil {
-			printError(cfg, "No current repository", err)
-		} else {
-			fmt.Printf("No current repository found.\n\n")
+// displayUsage collects and prints the usage for the targets (and if requested, the billing summary for the
+// owners), including anything that failed, returning the exit code for the result. If collection is stopped
+// early, the usage collected so far is printed, followed by the reason on stderr.
+func displayUsage(ctx context.Context, cfg config, gh *client.Client) int {
+	collector := usage.NewCollector(gh, cfg.collect)
+	targets, err := collector.Resolve(ctx)
+	if err != nil {
+		prefix := "Error getting targets"
+		if len(cfg.collect.Targets) == 0 {
+			prefix = "No current repository"
 		}
+		printError(cfg, prefix, stoppedCause(ctx, err))
 		printHelp()
-		return
+		return exitError
+	}
+	collected, err := collector.CollectTargets(ctx, targets)
+	var stopped StoppedError
+	if err != nil && !errors.As(err, &stopped) {
+		printError(cfg, "Error getting usage", err)
+		return exitError
+	}
+	shown := collected.Usage
+	if cfg.skip {
+		shown = withoutEmpty(collected.Usage)
+	}
+	report := format.Report{
+		Usage:      shown,
+		Failures:   collected.Failures,
+		Billing:    collected.Billing,
+		Cost:       cfg.cost,
+		Budgets:    cfg.budget,
+		Order:      cfg.order,
+		GroupBy:    cfg.group,
+		Workflows:  cfg.workflows,
+		Runs:       cfg.collect.Runs,
+		SelfHosted: cfg.selfHosted,
+	}
+	if !gh.IsHost(client.GitHubHost) {
+		report.Host = gh.Host
+	}
+	cfg.format.PrintUsage(report)
+	if cfg.verbose {
+		printRateLimit(os.Stderr, gh.RateLimit)
+		printCacheStats(os.Stderr, gh.Cache)
+	}
+	if stopped != "" {
+		_, _ = fmt.Fprintf(os.Stderr, "\n%s: the report only includes the usage collected before then\n", stopped)
+	}
+	if cfg.snapshot && stopped == "" {
+		if err := saveSnapshot(cfg, gh, collected.Usage); err != nil {
+			printError(cfg, "Error saving snapshot", err)
+			return exitError
+		}
+	}
+	statuses := format.CheckBudgets(report)
+	for _, status := range budget.Unmatched(statuses) {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: budget for %s %s, which isn't in the report\n", status.Scope, status.Name)
+	}
+	if budget.Exceeded(statuses) {
+		return exitOverBudget
+	}
+	if len(collected.Failures) > 0 || stopped != "" {
+		return exitPartial
 	}
-	var repoFlowUsage = make(map[*client.Repository]client.WorkflowUsage)
-	r := getRepoUsage(repo)
-	repoFlowUsage[repo] = r
-	cfg.format.PrintUsage(repoFlowUsage)
+	return exitOK
 }
 
-func tryDisplayAllSpecified(cfg config, targets []string) {
-	repos, err := getRepositories(targets)
-	if err != nil {
-		printError(cfg, "Error getting targets", err)
-		printHelp()
-		return
-	}
-	var repoFlowUsage = make(map[*client.Repository]client.WorkflowUsage)
-	for _, list := range repos {
-		for _, item := range list {
-			r := getRepoUsage(item)
-			if len(r) == 0 && cfg.skip {
-				continue
-			}
-			repoFlowUsage[item] = r
+// withoutEmpty returns the usage of the repositories that have workflows, leaving the usage it was given (which
+// is what's stored in snapshots) as it is
+func withoutEmpty(repoUsage client.RepoUsage) client.RepoUsage {
+	shown := make(client.RepoUsage, len(repoUsage))
+	for repo, flowUsage := range repoUsage {
+		if len(flowUsage) > 0 {
+			shown[repo] = flowUsage
 		}
 	}
-	cfg.format.PrintUsage(repoFlowUsage)
+	return shown
 }
 
-type repoMap map[*client.User][]*client.Repository
+// loadBudgets reads the budgets in the file, if there is one, and then adds or replaces them with the budget options
+func loadBudgets(path string, values []string) (*budget.Budgets, error) {
+	budgets := budget.New()
+	if path != "" {
+		var err error
+		if budgets, err = budget.Load(path); err != nil {
+			return nil, fmt.Errorf("could not load budgets: %w", err)
+		}
+	}
+	for _, value := range values {
+		if err := budgets.Set(value); err != nil {
+			return nil, fmt.Errorf("could not set budget: %w", err)
+		}
+	}
+	return budgets, nil
+}
 
 // printError prints an error message with varying detail based on error type and verbosity.
-// Known typed errors (UnknownRepoError, UnknownUserError, etc.) always print a clean,
+// Known typed errors (usage.UnknownRepoError, usage.UnknownUserError, etc.) always print a clean,
 // self-describing message without the prefix, as their messages already include full context.
 // HTTP errors from the GitHub API print the status code and message.
 // Other errors are only shown in full when --verbose is set; otherwise a brief message is shown.
 func printError(cfg config, prefix string, err error) {
 	if cfg.verbose {
-		_, _ = fmt.Fprintf(cfg.w, "%s: %s\n\n", prefix, err)
+		_, _ = fmt.Fprintf(cfg.stderr, "%s: %s\n\n", prefix, err)
 		return
 	}
 	if msg, ok := knownErrorMessage(err); ok {
-		_, _ = fmt.Fprintf(cfg.w, "%s\n\n", msg)
+		_, _ = fmt.Fprintf(cfg.stderr, "%s\n\n", msg)
 		return
 	}
 	var httpErr gogherrors.HTTPError
 	if errors.As(err, &httpErr) {
-		_, _ = fmt.Fprintf(cfg.w, "%s: HTTP %d: %s\n\n", prefix, httpErr.StatusCode, httpErr.Message)
+		_, _ = fmt.Fprintf(cfg.stderr, "%s: HTTP %d: %s\n\n", prefix, httpErr.StatusCode, httpErr.Message)
 		return
 	}
-	_, _ = fmt.Fprintf(cfg.w, "%s (use --verbose for details)\n\n", prefix)
+	_, _ = fmt.Fprintf(cfg.stderr, "%s (use --verbose for details)\n\n", prefix)
 }
 
 // knownErrorMessage checks if err contains a well-typed, self-describing error and returns
 // its clean message. These errors do not require --verbose to produce a useful message.
 func knownErrorMessage(err error) (string, bool) {
-	var unknownRepo UnknownRepoError
+	var unknownRepo usage.UnknownRepoError
 	if errors.As(err, &unknownRepo) {
 		return unknownRepo.Error(), true
 	}
-	var unknownUser UnknownUserError
+	var unknownUser usage.UnknownUserError
 	if errors.As(err, &unknownUser) {
 		return unknownUser.Error(), true
 	}
+	var noCurrentRepo usage.NoCurrentRepositoryError
+	if errors.As(err, &noCurrentRepo) {
+		return noCurrentRepo.Error(), true
+	}
 	var unexpectedHost client.UnexpectedHostError
 	if errors.As(err, &unexpectedHost) {
 		return unexpectedHost.Error(), true
 	}
+	var stopped StoppedError
+	if errors.As(err, &stopped) {
+		return stopped.Error(), true
+	}
 	var unexpectedUserType client.UnexpectedUserTypeError
 	if errors.As(err, &unexpectedUserType) {
 		return unexpectedUserType.Error(), true
@@ -168,90 +468,65 @@ func knownErrorMessage(err error) (string, bool) {
 	return "", false
 }
 
-func getRepositories(targets []string) (repoMap, error) {
-	repos := make(repoMap)
-	for _, target := range targets {
-		if strings.ContainsRune(target, '/') {
-			err := mapRepository(repos, target)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			err := mapOwner(repos, target)
-			if err != nil {
-				return nil, err
-			}
-		}
-	}
-	return repos, nil
-}
-
-func mapRepository(repos repoMap, repoName string) error {
-	repo, err := gh.GetRepository(repoName)
-	if err != nil {
-		return fmt.Errorf("could not get repository: %w", err)
+// printRateLimit reports how much of the API quota was used; it's written separately from the report
+// so that it doesn't interfere with machine-readable output
+func printRateLimit(w io.Writer, limiter *client.RateLimiter) {
+	if limiter == nil {
+		return
 	}
-	if repo == nil {
-		return UnknownRepoError(repoName)
+	stats := limiter.Stats()
+	_, _ = fmt.Fprintf(w, "\nAPI requests: %d (%d retried); rate limit quota used: %d", stats.Requests, stats.Retries, stats.Consumed)
+	if stats.Remaining >= 0 {
+		_, _ = fmt.Fprintf(w, "; %d of %d remaining, resets at %s", stats.Remaining, stats.Limit, stats.Reset.Format(time.Kitchen))
 	}
-
-	owner := repo.Owner
-	list := repos[owner]
-	if list == nil {
-		list = make([]*client.Repository, 0)
-	}
-	repos[owner] = append(list, repo)
-	return nil
+	_, _ = fmt.Fprintln(w)
 }
 
-func mapOwner(repos repoMap, userName string) error {
-	user, err := gh.GetUser(userName)
-	if err != nil {
-		return fmt.Errorf("could not get user: %w", err)
-	}
-	if user == nil {
-		return UnknownUserError(userName)
-	}
-
-	list := repos[user]
-	if list == nil {
-		list = make([]*client.Repository, 0)
-	}
-
-	ors, err := gh.GetAllRepositories(user)
-	if err != nil {
-		return fmt.Errorf("could not get repositories: %w", err)
+// printCacheStats reports how many API responses were served from the cache, if there is one
+func printCacheStats(w io.Writer, cache *client.Cache) {
+	if cache == nil {
+		return
 	}
-
-	list = append(list, ors...)
-	repos[user] = list
-	return nil
+	stats := cache.Stats()
+	_, _ = fmt.Fprintf(w, "API cache: %d fresh, %d revalidated (not modified), %d downloaded\n", stats.Fresh, stats.Revalidated, stats.Fetched)
 }
 
-func getRepoUsage(repo *client.Repository) client.WorkflowUsage {
-	workflows, err := gh.GetWorkflows(*repo)
-	if err != nil {
-		panic(err)
+// subcommand returns the command named by the first argument and the arguments that follow it, or nothing if the
+// first argument isn't a command. A user or organization with the same name as a command can still be targeted by
+// putting it after -- or an option (e.g. gh actions-usage -- history).
+func subcommand(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", args
 	}
-
-	var result = make(client.WorkflowUsage)
-	for _, flow := range workflows {
-		usage, err := gh.GetWorkflowUsage(*repo, flow)
-		if err != nil {
-			panic(err)
-		}
-		result[flow] = usage.TotalMs()
+	switch args[0] {
+	case "history", "diff":
+		return args[0], args[1:]
 	}
-
-	return result
+	return "", args
 }
 
 func printHelp() {
-	fmt.Println("USAGE: gh actions-usage [--output=human|tsv] [--skip] [--verbose] [target]...\n\n" +
-		"Gets the usage for all workflows in one or more GitHub repositories.\n\n" +
-		"If target is not specified, actions-usage will attempt to get usage for a git repo in the current working directory.\n" +
-		"Target can be one of:\n" +
-		"- username (e.g. geoffreywiseman)\n" +
-		"- organization (e.g. codiform)\n" +
-		"- repository (e.g. codiform/gh-actions-usage)")
+	_, _ = fmt.Fprintln(os.Stderr, "USAGE: gh actions-usage [--output=human|tsv|json] [--skip] [--verbose] [--concurrency=n] [--billing] [--cost] [--rates=file] [--hostname=host] [--runs] [--jobs] [--self-hosted]\n"+
+		"       [--exclude-archived] [--exclude-forks] [--visibility=public|private|internal] [--topic=topic]... [--include=pattern]... [--exclude=pattern]...\n"+
+		"       [--workflow-state=state]... [--workflow-path=glob]... [--min-usage=duration]\n"+
+		"       [--sort=name|usage|workflows[:asc|:desc]] [--top=n] [--top-repos=n] [--group-by=workflow|repo|owner]\n"+
+		"       [--budget=limit]... [--budget-file=path] [--cache-ttl=duration] [--no-cache]\n"+
+		"       [--record=dir | --replay=dir] [--timeout=duration]\n"+
+		"       [--snapshot] [--history-file=path] [target]...\n"+
+		"       gh actions-usage history [--output=human|tsv|json] [--history-file=path] [target]...\n"+
+		"       gh actions-usage diff [--output=human|tsv|json] [--history-file=path] <before> <after>\n\n"+
+		"Gets the usage for all workflows in one or more GitHub repositories.\n\n"+
+		"If target is not specified, actions-usage will attempt to get usage for a git repo in the current working directory.\n"+
+		"Target can be one of:\n"+
+		"- username (e.g. geoffreywiseman)\n"+
+		"- organization (e.g. codiform)\n"+
+		"- repository (e.g. codiform/gh-actions-usage)\n"+
+		"- @name, for a set of targets in a config file\n"+
+		"To target a user or organization named history or diff, put it after -- (e.g. gh actions-usage -- history).\n\n"+
+		"Targets and option defaults can be set in .gh-actions-usage.yml in the current directory, or in\n"+
+		"actions-usage/config.yml in the gh config directory; options on the command line take precedence.\n\n"+
+		"With --snapshot, the usage is also stored so that the history command can show how it changed over time.\n"+
+		"Workflows hidden by --workflow-state, --workflow-path or --min-usage are still counted in the totals.\n"+
+		"Exits with 2 if some of the usage couldn't be collected or collection was interrupted or timed out, or 3 if the\n"+
+		"usage is over a budget.")
 }
@@ -2,26 +2,33 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/cli/go-gh/pkg/api"
+	"github.com/geoffreywiseman/gh-actions-usage/budget"
 	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/usage"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var errGeneric = errors.New("something went wrong")
 
 // cfgVerbose returns a config with verbose enabled, writing to w.
 func cfgVerbose(w io.Writer) config {
-	return config{verbose: true, w: w}
+	return config{verbose: true, stderr: w}
 }
 
 // cfgQuiet returns a config with verbose disabled, writing to w.
 func cfgQuiet(w io.Writer) config {
-	return config{verbose: false, w: w}
+	return config{verbose: false, stderr: w}
 }
 
 func TestPrintError_Verbose_GenericError(t *testing.T) {
@@ -53,7 +60,7 @@ func TestPrintError_Verbose_HTTPError(t *testing.T) {
 func TestPrintError_UnknownRepo(t *testing.T) {
 	// Given
 	var out bytes.Buffer
-	err := UnknownRepoError("codiform/missing")
+	err := usage.UnknownRepoError("codiform/missing")
 
 	// When
 	printError(cfgQuiet(&out), "Error getting targets", err)
@@ -65,7 +72,7 @@ func TestPrintError_UnknownRepo(t *testing.T) {
 func TestPrintError_UnknownRepo_Wrapped(t *testing.T) {
 	// Given
 	var out bytes.Buffer
-	err := fmt.Errorf("outer: %w", UnknownRepoError("codiform/missing"))
+	err := fmt.Errorf("outer: %w", usage.UnknownRepoError("codiform/missing"))
 
 	// When
 	printError(cfgQuiet(&out), "Error getting targets", err)
@@ -77,7 +84,7 @@ func TestPrintError_UnknownRepo_Wrapped(t *testing.T) {
 func TestPrintError_UnknownUser(t *testing.T) {
 	// Given
 	var out bytes.Buffer
-	err := UnknownUserError("johndoe")
+	err := usage.UnknownUserError("johndoe")
 
 	// When
 	printError(cfgQuiet(&out), "Error getting targets", err)
@@ -86,6 +93,106 @@ func TestPrintError_UnknownUser(t *testing.T) {
 	assert.Equal(t, "Unknown user: johndoe\n\n", out.String())
 }
 
+func TestPrintError_NoCurrentRepository(t *testing.T) {
+	// Given
+	var out bytes.Buffer
+
+	// When
+	printError(cfgQuiet(&out), "No current repository", usage.NoCurrentRepositoryError{})
+
+	// Then
+	assert.Equal(t, "No current repository found.\n\n", out.String())
+}
+
+func TestPrintError_Stopped(t *testing.T) {
+	// Given
+	var out bytes.Buffer
+	ctx, cancel := context.WithCancelCause(t.Context())
+	cancel(StoppedError("Interrupted"))
+
+	// When
+	printError(cfgQuiet(&out), "Error getting targets", stoppedCause(ctx, fmt.Errorf("could not get user: %w", context.Canceled)))
+
+	// Then
+	assert.Equal(t, "Interrupted\n\n", out.String())
+}
+
+func TestCheckGroupBy(t *testing.T) {
+	require.NoError(t, checkGroupBy(config{sort: "usage", topRepos: 3}))
+	require.NoError(t, checkGroupBy(config{groupBy: "owner", top: 3}))
+	require.ErrorIs(t, checkGroupBy(config{groupBy: "owner", sort: "usage"}), ConflictingOptionsError("--group-by and --sort"))
+	require.ErrorIs(t, checkGroupBy(config{groupBy: "repo", topRepos: 3}), ConflictingOptionsError("--group-by and --top-repos"))
+}
+
+func TestClientHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostname string
+		ghHost   string
+		targets  []string
+		expected string
+	}{
+		{"current repository", "", "", nil, "github.example.com"},
+		{"hostname", "ghes.example.com", "", nil, "ghes.example.com"},
+		{"gh host", "", "ghes.example.com", nil, ""},
+		{"targets", "", "", []string{"codiform"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			t.Setenv("GH_REPO", "github.example.com/platform/deployer")
+			t.Setenv("GH_HOST", tt.ghHost)
+			cfg := config{hostname: tt.hostname, collect: usage.Options{Targets: tt.targets}}
+
+			// When
+			host := clientHost(cfg)
+
+			// Then
+			assert.Equal(t, tt.expected, host)
+		})
+	}
+}
+
+func TestStoppableContext_Timeout(t *testing.T) {
+	// Given
+	ctx, stop := stoppableContext(time.Millisecond)
+	defer stop()
+
+	// When
+	<-ctx.Done()
+
+	// Then
+	assert.Equal(t, StoppedError("Timed out after 1ms"), context.Cause(ctx))
+}
+
+func TestStoppableContext_NoTimeout(t *testing.T) {
+	// Given
+	ctx, stop := stoppableContext(0)
+
+	// When
+	stop()
+
+	// Then
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.Equal(t, context.Canceled, context.Cause(ctx))
+}
+
+func TestWatchInterrupts_CancelEndsWatcher(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancelCause(t.Context())
+	done := watchInterrupts(ctx, cancel)
+
+	// When
+	cancel(nil)
+
+	// Then
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watcher still running after cancel")
+	}
+}
+
 func TestPrintError_UnexpectedHost(t *testing.T) {
 	// Given
 	var out bytes.Buffer
@@ -145,3 +252,87 @@ func TestPrintError_GenericError(t *testing.T) {
 	// Then
 	assert.Equal(t, "No current repository (use --verbose for details)\n\n", out.String())
 }
+
+func TestPrintRateLimit_NoLimiter(t *testing.T) {
+	// Given
+	var out bytes.Buffer
+
+	// When
+	printRateLimit(&out, nil)
+
+	// Then
+	assert.Empty(t, out.String())
+}
+
+func TestLoadBudgets(t *testing.T) {
+	// Given
+	path := filepath.Join(t.TempDir(), "budgets.yml")
+	require.NoError(t, os.WriteFile(path, []byte("total: 1000\nowners:\n  codiform: $50\n"), 0o600))
+
+	// When
+	budgets, err := loadBudgets(path, []string{"codiform=$25", "codiform/api=100"})
+
+	// Then
+	require.NoError(t, err)
+	assert.Equal(t, &budget.Limit{Amount: 1000, Unit: budget.Minutes}, budgets.Total)
+	assert.Equal(t, budget.Limit{Amount: 25, Unit: budget.Dollars}, budgets.Owners["codiform"])
+	assert.Equal(t, budget.Limit{Amount: 100, Unit: budget.Minutes}, budgets.Repositories["codiform/api"])
+}
+
+func TestLoadBudgets_Invalid(t *testing.T) {
+	_, err := loadBudgets("", []string{"codiform=lots"})
+
+	assert.ErrorIs(t, err, budget.InvalidLimitError("lots"))
+}
+
+func TestPrintCacheStats(t *testing.T) {
+	// Given
+	var out bytes.Buffer
+	cache := client.NewCache(t.TempDir(), 0, nil)
+
+	// When
+	printCacheStats(&out, cache)
+	printCacheStats(&out, nil)
+
+	// Then
+	assert.Equal(t, "API cache: 0 fresh, 0 revalidated (not modified), 0 downloaded\n", out.String())
+}
+
+func TestSubcommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		command string
+		rest    []string
+	}{
+		{"none", []string{}, "", []string{}},
+		{"history", []string{"history", "codiform"}, "history", []string{"codiform"}},
+		{"diff", []string{"diff", "@1", "@-1"}, "diff", []string{"@1", "@-1"}},
+		{"target", []string{"codiform"}, "", []string{"codiform"}},
+		{"escaped", []string{"--", "history"}, "", []string{"--", "history"}},
+		{"after option", []string{"--skip", "diff"}, "", []string{"--skip", "diff"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command, rest := subcommand(tt.args)
+
+			assert.Equal(t, tt.command, command)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+func TestWithoutEmpty(t *testing.T) {
+	// Given
+	active := &client.Repository{FullName: "codiform/gh-actions-usage"}
+	empty := &client.Repository{FullName: "kim0/salt-states"}
+	ci := client.Workflow{ID: 1, Path: ".github/workflows/ci.yml"}
+	repoUsage := client.RepoUsage{active: {ci: &client.Usage{}}, empty: {}}
+
+	// When
+	shown := withoutEmpty(repoUsage)
+
+	// Then
+	assert.Equal(t, client.RepoUsage{active: {ci: &client.Usage{}}}, shown)
+	assert.Len(t, repoUsage, 2, "the collected usage, which is snapshotted, keeps the empty repositories")
+}
@@ -59,11 +59,13 @@ func (m *RestMock) Put(path string, body io.Reader, response any) error {
 // Request is a mock implementation of RESTClient.Request
 func (m *RestMock) Request(method, path string, body io.Reader) (*http.Response, error) {
 	args := m.Called(method, path, body)
-	return nil, args.Error(0) //nolint:wrapcheck
+	response, _ := args.Get(0).(*http.Response)
+	return response, args.Error(1) //nolint:wrapcheck
 }
 
 // RequestWithContext is a mock implementation of RESTClient.RequestWithContext
 func (m *RestMock) RequestWithContext(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
 	args := m.Called(ctx, method, path, body)
-	return nil, args.Error(0) //nolint:wrapcheck
+	response, _ := args.Get(0).(*http.Response)
+	return response, args.Error(1) //nolint:wrapcheck
 }
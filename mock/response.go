@@ -0,0 +1,22 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// JSONResponse builds a successful response with a JSON body, as returned by RESTClient.Request; if next is not
+// empty, the response has a Link header pointing to it as the next page
+func JSONResponse(body string, next string) *http.Response {
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	if next != "" {
+		header.Set("Link", `<`+next+`>; rel="next"`)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
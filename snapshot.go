@@ -0,0 +1,113 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/format"
+	"github.com/geoffreywiseman/gh-actions-usage/history"
+)
+
+// historyStore returns the store at path, or the default store in the gh config directory if path is empty
+func historyStore(path string) history.Store {
+	if path == "" {
+		return history.DefaultStore()
+	}
+	return history.Store{Path: path}
+}
+
+// saveSnapshot adds the usage to the history so that it's still available after the billing period ends; a
+// replayed recording is stored as of when it was recorded, so it stays in the right billing period
+func saveSnapshot(cfg config, gh *client.Client, usage client.RepoUsage) error {
+	store := historyStore(cfg.historyFile)
+	taken := time.Now()
+	if !gh.RecordedAt.IsZero() {
+		taken = gh.RecordedAt
+	}
+	snapshot := history.NewSnapshot(taken, usage)
+	if !gh.IsHost(client.GitHubHost) {
+		snapshot.Host = gh.Host
+	}
+	if err := store.Append(snapshot); err != nil {
+		return fmt.Errorf("could not save snapshot: %w", err)
+	}
+	if cfg.verbose {
+		_, _ = fmt.Fprintf(os.Stderr, "Saved snapshot to %s\n", store.Path)
+	}
+	return nil
+}
+
+// runHistory is the `history` subcommand, which shows the trend in usage across the stored snapshots,
+// optionally limited to some owners or repositories
+func runHistory(args []string) int {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	output := flags.String("output", "human", "Output format: human, TSV or JSON (machine readable)")
+	file := flags.String("history-file", "", "File of snapshots to read (default: history.jsonl in the gh config directory)")
+	hostname := flags.String("hostname", "", "Only show snapshots from this GitHub host (default: the gh host, or GH_HOST)")
+	flags.Usage = printHistoryHelp
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	settings, err := loadConfig(flags, false)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Configuration: %s\n\n", err)
+		return exitError
+	}
+	targets, err := settings.expandTargets(flags.Args())
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHistoryHelp()
+		return exitError
+	}
+
+	formatter, err := format.GetFormatter(*output)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Invalid Option: %s\n\n", err)
+		printHistoryHelp()
+		return exitError
+	}
+	if *output != "json" {
+		fmt.Printf("GitHub Actions Usage History (%s)\n\n", getVersion())
+	}
+
+	snapshots, err := historyStore(*file).Load()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error reading history: %s\n\n", err)
+		return exitError
+	}
+	formatter.PrintHistory(selectSnapshots(history.ForHost(snapshots, snapshotHost(*hostname)), targets))
+	return exitOK
+}
+
+// snapshotHost returns the host to compare snapshots from: the hostname option, or the gh host
+func snapshotHost(hostname string) string {
+	if hostname == "" {
+		return client.DefaultHost()
+	}
+	return hostname
+}
+
+// selectSnapshots limits the snapshots to the targets, leaving out any snapshots without them
+func selectSnapshots(snapshots []history.Snapshot, targets []string) []history.Snapshot {
+	if len(targets) == 0 {
+		return snapshots
+	}
+	selected := make([]history.Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		if s := snapshot.Select(targets); len(s.Repositories) > 0 {
+			selected = append(selected, s)
+		}
+	}
+	return selected
+}
+
+func printHistoryHelp() {
+	_, _ = fmt.Fprintln(os.Stderr, "USAGE: gh actions-usage history [--output=human|tsv|json] [--history-file=path] [--hostname=host] [target]...\n\n"+
+		"Shows how usage changed across the snapshots stored by running with --snapshot,\n"+
+		"using only the snapshots from the gh host (or --hostname).\n\n"+
+		"If targets are specified, only the matching owners (e.g. codiform) and repositories\n"+
+		"(e.g. codiform/gh-actions-usage) are shown.")
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveSnapshot_Replay(t *testing.T) {
+	// Given
+	cfg := config{historyFile: filepath.Join(t.TempDir(), "history.jsonl")}
+	recorded := time.Date(2026, 9, 28, 12, 0, 0, 0, time.UTC)
+	gh := client.Client{RecordedAt: recorded}
+
+	// When
+	err := saveSnapshot(cfg, &gh, client.RepoUsage{})
+
+	// Then
+	require.NoError(t, err)
+	snapshots, err := historyStore(cfg.historyFile).Load()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.True(t, recorded.Equal(snapshots[0].Time))
+}
@@ -0,0 +1,230 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	gogherrors "github.com/cli/go-gh/pkg/api"
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// usageCollector fans out workflow and usage requests across repositories while keeping at most
+// `Concurrency` requests in flight. Failures for a repository or workflow are recorded and collection
+// continues; a fatal failure (e.g. bad credentials) cancels any work that hasn't started yet.
+type usageCollector struct {
+	ctx      context.Context //nolint:containedctx // scoped to a single collectUsage call
+	cancel   context.CancelCauseFunc
+	source   UsageSource
+	runs     RunSource
+	options  Options
+	since    time.Time
+	progress Progress
+	sem      chan struct{}
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	usage    client.RepoUsage
+	failures []client.UsageError
+}
+
+// collectUsage gets the workflow usage for each of the repositories, making up to options.Concurrency API
+// requests at a time. Ordering of the output is left to the formatters, which sort the summary.
+// Repositories and workflows that fail are returned as failures alongside the usage that was
+// collected; an error is only returned if collection had to stop, unless parent was cancelled, when
+// the usage collected so far is returned along with the cause.
+func collectUsage(parent context.Context, source UsageSource, repos []*client.Repository, options Options) (client.RepoUsage, []client.UsageError, error) {
+	ctx, cancel := context.WithCancelCause(parent)
+	defer cancel(nil)
+
+	asOf := options.AsOf
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	c := &usageCollector{
+		ctx:      ctx,
+		cancel:   cancel,
+		source:   source,
+		options:  options,
+		since:    client.BillingPeriodStart(asOf),
+		progress: Progress{Repositories: len(repos)},
+		sem:      make(chan struct{}, options.Concurrency),
+		usage:    make(client.RepoUsage, len(repos)),
+	}
+	c.runs, _ = source.(RunSource)
+	for _, repo := range repos {
+		c.wg.Add(1)
+		go c.collectRepository(repo)
+	}
+	c.wg.Wait()
+
+	if parent.Err() != nil {
+		return c.usage, c.failures, context.Cause(parent)
+	}
+	if err := context.Cause(ctx); err != nil {
+		return nil, nil, err
+	}
+	return c.usage, c.failures, nil
+}
+
+func (c *usageCollector) collectRepository(repo *client.Repository) {
+	defer c.wg.Done()
+	if !c.acquire() {
+		return
+	}
+	workflows, err := c.source.GetWorkflows(c.ctx, *repo)
+	c.release()
+	if err != nil {
+		c.fail(client.UsageError{Repository: repo, Err: err})
+		c.advance(func(progress *Progress) { progress.Listed++ })
+		return
+	}
+
+	c.mu.Lock()
+	c.usage[repo] = make(client.WorkflowUsage, len(workflows))
+	c.mu.Unlock()
+	c.advance(func(progress *Progress) {
+		progress.Listed++
+		progress.Workflows += len(workflows)
+	})
+
+	for _, flow := range workflows {
+		c.wg.Add(1)
+		go c.collectWorkflow(repo, flow)
+	}
+}
+
+func (c *usageCollector) collectWorkflow(repo *client.Repository, flow client.Workflow) {
+	defer c.wg.Done()
+	if !c.acquire() {
+		return
+	}
+	usage, err := c.source.GetWorkflowUsage(c.ctx, *repo, flow)
+	c.release()
+	c.advance(func(progress *Progress) { progress.Collected++ })
+	if err != nil {
+		c.fail(client.UsageError{Repository: repo, Workflow: &flow, Err: err})
+		return
+	}
+
+	c.mu.Lock()
+	c.usage[repo][flow] = usage
+	c.mu.Unlock()
+
+	if c.options.Runs {
+		c.collectRuns(repo, flow, usage)
+	}
+}
+
+// collectRuns lists the workflow's runs in the billing period, then gets the usage of each of them
+func (c *usageCollector) collectRuns(repo *client.Repository, flow client.Workflow, usage *client.Usage) {
+	if !c.acquire() {
+		return
+	}
+	runs, err := c.runs.GetWorkflowRuns(c.ctx, *repo, flow, c.since)
+	c.release()
+	if err != nil {
+		c.fail(client.UsageError{Repository: repo, Workflow: &flow, Err: err})
+		return
+	}
+	for _, run := range runs {
+		c.wg.Add(1)
+		go c.collectRun(repo, flow, usage, run)
+	}
+}
+
+func (c *usageCollector) collectRun(repo *client.Repository, flow client.Workflow, usage *client.Usage, run client.Run) {
+	defer c.wg.Done()
+	if !c.acquire() {
+		return
+	}
+	runUsage, err := c.runs.GetRunUsage(c.ctx, *repo, run)
+	c.release()
+	if err != nil {
+		c.fail(client.UsageError{Repository: repo, Workflow: &flow, Err: err})
+		return
+	}
+
+	if c.options.Jobs {
+		if !c.acquire() {
+			return
+		}
+		runUsage.Jobs, err = c.runs.GetRunJobs(c.ctx, *repo, run)
+		c.release()
+		if err != nil {
+			c.fail(client.UsageError{Repository: repo, Workflow: &flow, Err: err})
+		}
+	}
+
+	c.mu.Lock()
+	usage.Runs = append(usage.Runs, runUsage)
+	c.mu.Unlock()
+}
+
+// advance updates the progress, and reports it if there's a callback for it
+func (c *usageCollector) advance(update func(progress *Progress)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	update(&c.progress)
+	if c.options.Progress != nil {
+		c.options.Progress(c.progress)
+	}
+}
+
+// fail records a failure, cancelling collection if the failure would affect every other request too;
+// requests that failed because collection was cancelled aren't failures of their own
+func (c *usageCollector) fail(failure client.UsageError) {
+	if c.ctx.Err() != nil && (errors.Is(failure.Err, context.Canceled) || errors.Is(failure.Err, context.DeadlineExceeded)) {
+		return
+	}
+	if isFatal(failure.Err) {
+		c.cancel(failure)
+		return
+	}
+	c.mu.Lock()
+	c.failures = append(c.failures, failure)
+	c.mu.Unlock()
+}
+
+// isFatal returns true for failures that aren't specific to a repository or workflow
+func isFatal(err error) bool {
+	var httpErr gogherrors.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusUnauthorized
+}
+
+// acquire waits for a free request slot, returning false if collection was cancelled first
+func (c *usageCollector) acquire() bool {
+	select {
+	case c.sem <- struct{}{}:
+	case <-c.ctx.Done():
+		return false
+	}
+	if c.ctx.Err() != nil {
+		c.release()
+		return false
+	}
+	return true
+}
+
+func (c *usageCollector) release() {
+	<-c.sem
+}
+
+// collectBilling gets the billing summary for each of the owners, by login. Owners whose billing isn't
+// available (e.g. because the token belongs to someone who isn't an owner or billing manager) are left out.
+func collectBilling(ctx context.Context, source BillingSource, owners []*client.User) (map[string]*client.Billing, []client.UsageError) {
+	billing := make(map[string]*client.Billing, len(owners))
+	var failures []client.UsageError
+	for _, owner := range owners {
+		summary, err := source.GetBilling(ctx, owner)
+		if err != nil {
+			failures = append(failures, client.UsageError{Owner: owner, Err: err})
+			continue
+		}
+		if summary != nil {
+			billing[owner.Login] = summary
+		}
+	}
+	return billing, failures
+}
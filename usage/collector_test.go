@@ -0,0 +1,152 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/pkg/api"
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errGeneric = errors.New("something went wrong")
+	errStopped = errors.New("stopped")
+)
+
+// stoppingSource is a fake source that stops collection when it's asked for the usage of a workflow, either
+// after getting the usage or instead of it, like a request that was in flight
+type stoppingSource struct {
+	*fake.Source
+	stop     context.CancelCauseFunc
+	inFlight bool
+}
+
+func (s stoppingSource) GetWorkflowUsage(ctx context.Context, repository client.Repository, workflow client.Workflow) (*client.Usage, error) {
+	s.stop(errStopped)
+	if s.inFlight {
+		return nil, context.Canceled
+	}
+	return s.Source.GetWorkflowUsage(context.WithoutCancel(ctx), repository, workflow)
+}
+
+func TestCollectUsage(t *testing.T) {
+	// Given
+	source := fake.New()
+	first := source.AddRepository("codiform/gh-actions-usage", client.Repository{})
+	second := source.AddRepository("codiform/terraform-tools", client.Repository{})
+	ci := source.AddWorkflow(first, client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml"}, map[string]uint{"UBUNTU": 500})
+	release := source.AddWorkflow(first, client.Workflow{Name: "Release", Path: ".github/workflows/release.yml"}, map[string]uint{"UBUNTU": 1500})
+
+	// When
+	usage, failures, err := collectUsage(t.Context(), source, []*client.Repository{first, second}, Options{Concurrency: 2})
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+	require.Len(t, usage, 2)
+	assert.Equal(t, uint(500), usage[first][ci].TotalMs())
+	assert.Equal(t, map[string]uint{"UBUNTU": 1500}, usage[first][release].RunnerMs())
+	assert.Empty(t, usage[second])
+}
+
+func TestCollectUsage_PartialFailure(t *testing.T) {
+	// Given
+	source := fake.New()
+	repo := source.AddRepository("codiform/gh-actions-usage", client.Repository{})
+	disabled := source.AddRepository("codiform/disabled", client.Repository{})
+	ci := source.AddWorkflow(repo, client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml"}, map[string]uint{"UBUNTU": 500})
+	release := source.AddWorkflow(repo, client.Workflow{Name: "Release", Path: ".github/workflows/release.yml"}, map[string]uint{"UBUNTU": 1500})
+	source.FailWorkflow(repo.FullName, release.Path, errGeneric)
+	source.FailRepository(disabled.FullName, api.HTTPError{StatusCode: 403, Message: "Actions disabled"})
+
+	// When
+	usage, failures, err := collectUsage(t.Context(), source, []*client.Repository{repo, disabled}, Options{Concurrency: 2})
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, usage, 1)
+	assert.Equal(t, uint(500), usage[repo][ci].TotalMs())
+	assert.NotContains(t, usage[repo], release)
+	require.Len(t, failures, 2)
+	assert.ElementsMatch(t, []string{
+		"codiform/gh-actions-usage .github/workflows/release.yml: could not get workflow usage: something went wrong",
+		"codiform/disabled: HTTP 403: Actions disabled",
+	}, []string{failures[0].Error(), failures[1].Error()})
+}
+
+func TestCollectUsage_Fatal(t *testing.T) {
+	// Given
+	source := fake.New()
+	repo := source.AddRepository("codiform/gh-actions-usage", client.Repository{})
+	source.FailRepository(repo.FullName, api.HTTPError{StatusCode: 401, Message: "Bad credentials"})
+
+	// When
+	usage, failures, err := collectUsage(t.Context(), source, []*client.Repository{repo}, Options{Concurrency: 1})
+
+	// Then
+	require.Error(t, err)
+	assert.Nil(t, usage)
+	assert.Nil(t, failures)
+}
+
+func TestCollectUsage_Runs(t *testing.T) {
+	// Given
+	source := fake.New()
+	repo := source.AddRepository("codiform/gh-actions-usage", client.Repository{})
+	ci := source.AddWorkflow(repo, client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml"}, map[string]uint{"UBUNTU": 500})
+	source.AddRun(repo, ci, client.Run{Number: 1, CreatedAt: time.Now()}, 150, map[string]uint{"UBUNTU": 200})
+	source.AddRun(repo, ci, client.Run{Number: 2, CreatedAt: time.Now()}, 250, map[string]uint{"UBUNTU": 300})
+
+	// When
+	usage, failures, err := collectUsage(t.Context(), source, []*client.Repository{repo}, Options{Concurrency: 2, Runs: true})
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+	runs := usage[repo][ci].Runs
+	require.Len(t, runs, 2)
+	ms := map[uint]uint{}
+	for _, run := range runs {
+		ms[run.Run.Number] = run.Usage.TotalMs()
+	}
+	assert.Equal(t, map[uint]uint{1: 200, 2: 300}, ms)
+}
+
+func TestCollectUsage_Stopped(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancelCause(t.Context())
+	source := stoppingSource{Source: fake.New(), stop: cancel}
+	repo := source.AddRepository("codiform/gh-actions-usage", client.Repository{})
+	ci := source.AddWorkflow(repo, client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml"}, map[string]uint{"UBUNTU": 500})
+	source.AddRun(repo, ci, client.Run{CreatedAt: time.Now()}, 150, map[string]uint{"UBUNTU": 200})
+
+	// When
+	usage, failures, err := collectUsage(ctx, source, []*client.Repository{repo}, Options{Concurrency: 1, Runs: true})
+
+	// Then
+	require.ErrorIs(t, err, errStopped)
+	assert.Empty(t, failures)
+	assert.Equal(t, uint(500), usage[repo][ci].TotalMs())
+	assert.Empty(t, usage[repo][ci].Runs)
+}
+
+func TestCollectUsage_StoppedInFlight(t *testing.T) {
+	// Given
+	ctx, cancel := context.WithCancelCause(t.Context())
+	source := stoppingSource{Source: fake.New(), stop: cancel, inFlight: true}
+	repo := source.AddRepository("codiform/gh-actions-usage", client.Repository{})
+	source.AddWorkflow(repo, client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml"}, map[string]uint{"UBUNTU": 500})
+
+	// When
+	usage, failures, err := collectUsage(ctx, source, []*client.Repository{repo}, Options{Concurrency: 1})
+
+	// Then
+	require.ErrorIs(t, err, errStopped)
+	assert.Empty(t, failures)
+	assert.Empty(t, usage[repo])
+}
@@ -0,0 +1,129 @@
+package usage
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// RepositoryFilter decides which of an owner's repositories are collected; repositories that are targeted by
+// name are always collected
+type RepositoryFilter struct {
+	ExcludeArchived bool
+	ExcludeForks    bool
+	// Visibility is public, private or internal to only collect repositories with that visibility, or empty
+	Visibility string
+	// Topics are topics that a repository must all have, compared without regard to case
+	Topics []string
+	// Include are patterns that a repository must match one of, if there are any
+	Include []NamePattern
+	// Exclude are patterns that a repository must not match any of
+	Exclude []NamePattern
+}
+
+// InvalidVisibilityError is an error when the visibility to filter by isn't one that GitHub supports
+type InvalidVisibilityError string
+
+// Error returns a formatted error message for InvalidVisibilityError
+func (e InvalidVisibilityError) Error() string {
+	return fmt.Sprintf("Invalid visibility: %s (must be public, private or internal)", string(e))
+}
+
+// Validate checks the options that can't be checked while they're parsed
+func (f RepositoryFilter) Validate() error {
+	switch f.Visibility {
+	case "", "public", "private", "internal":
+		return nil
+	default:
+		return InvalidVisibilityError(f.Visibility)
+	}
+}
+
+// Matches returns true if the repository should be collected
+func (f RepositoryFilter) Matches(repo *client.Repository) bool {
+	switch {
+	case f.ExcludeArchived && repo.Archived:
+		return false
+	case f.ExcludeForks && repo.Fork:
+		return false
+	case f.Visibility != "" && !strings.EqualFold(repo.EffectiveVisibility(), f.Visibility):
+		return false
+	}
+	for _, topic := range f.Topics {
+		if !slices.ContainsFunc(repo.Topics, func(t string) bool { return strings.EqualFold(t, topic) }) {
+			return false
+		}
+	}
+	if len(f.Include) > 0 && !matchesAny(f.Include, repo) {
+		return false
+	}
+	return !matchesAny(f.Exclude, repo)
+}
+
+// apply returns the repositories that match the filter
+func (f RepositoryFilter) apply(repos []*client.Repository) []*client.Repository {
+	matched := make([]*client.Repository, 0, len(repos))
+	for _, repo := range repos {
+		if f.Matches(repo) {
+			matched = append(matched, repo)
+		}
+	}
+	return matched
+}
+
+// NamePattern matches repository names, either with a glob (e.g. api-*) or a regular expression between
+// slashes (e.g. /^api-(v1|v2)$/). Patterns containing a slash outside of a regular expression match the
+// full name (e.g. codiform/api-*).
+type NamePattern struct {
+	glob   string
+	regexp *regexp.Regexp
+}
+
+// ParseNamePattern returns the pattern for a glob or a /regexp/, or an error if it isn't valid
+func ParseNamePattern(pattern string) (NamePattern, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return NamePattern{}, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		return NamePattern{regexp: re}, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return NamePattern{}, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+	}
+	return NamePattern{glob: pattern}, nil
+}
+
+// String returns the pattern as it was parsed
+func (p NamePattern) String() string {
+	if p.regexp != nil {
+		return "/" + p.regexp.String() + "/"
+	}
+	return p.glob
+}
+
+// Matches returns true if the repository's name (or full name) matches the pattern
+func (p NamePattern) Matches(repo *client.Repository) bool {
+	if p.regexp != nil {
+		return p.regexp.MatchString(repo.Name) || p.regexp.MatchString(repo.FullName)
+	}
+	name := repo.Name
+	if strings.Contains(p.glob, "/") {
+		name = repo.FullName
+	}
+	matched, _ := path.Match(p.glob, name)
+	return matched
+}
+
+func matchesAny(patterns []NamePattern, repo *client.Repository) bool {
+	for _, p := range patterns {
+		if p.Matches(repo) {
+			return true
+		}
+	}
+	return false
+}
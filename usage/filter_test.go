@@ -0,0 +1,80 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleRepositories() []*client.Repository {
+	return []*client.Repository{
+		{FullName: "codiform/api-gateway", Name: "api-gateway", Visibility: "internal", Topics: []string{"go", "service"}},
+		{FullName: "codiform/api-legacy", Name: "api-legacy", Private: true, Archived: true},
+		{FullName: "codiform/gh-actions-usage", Name: "gh-actions-usage", Topics: []string{"go", "gh-extension"}},
+		{FullName: "codiform/terraform-aws", Name: "terraform-aws", Fork: true},
+	}
+}
+
+func filteredNames(filter RepositoryFilter) []string {
+	names := make([]string, 0)
+	for _, repo := range filter.apply(sampleRepositories()) {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+func mustParsePatterns(t *testing.T, patterns ...string) []NamePattern {
+	t.Helper()
+	parsed := make([]NamePattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		p, err := ParseNamePattern(pattern)
+		require.NoError(t, err)
+		parsed = append(parsed, p)
+	}
+	return parsed
+}
+
+func TestRepositoryFilter(t *testing.T) {
+	include := mustParsePatterns(t, "api-*")
+	exclude := mustParsePatterns(t, "codiform/*-legacy")
+	regexp := mustParsePatterns(t, "/^(gh|terraform)-/")
+
+	all := []string{"api-gateway", "api-legacy", "gh-actions-usage", "terraform-aws"}
+	assert.Equal(t, all, filteredNames(RepositoryFilter{}))
+	assert.Equal(t, []string{"api-gateway", "gh-actions-usage", "terraform-aws"}, filteredNames(RepositoryFilter{ExcludeArchived: true}))
+	assert.Equal(t, []string{"api-gateway", "api-legacy", "gh-actions-usage"}, filteredNames(RepositoryFilter{ExcludeForks: true}))
+	assert.Equal(t, []string{"gh-actions-usage", "terraform-aws"}, filteredNames(RepositoryFilter{Visibility: "public"}))
+	assert.Equal(t, []string{"api-legacy"}, filteredNames(RepositoryFilter{Visibility: "private"}))
+	assert.Equal(t, []string{"api-gateway", "gh-actions-usage"}, filteredNames(RepositoryFilter{Topics: []string{"Go"}}))
+	assert.Equal(t, []string{"api-gateway"}, filteredNames(RepositoryFilter{Topics: []string{"go", "service"}}))
+	assert.Equal(t, []string{"api-gateway", "api-legacy"}, filteredNames(RepositoryFilter{Include: include}))
+	assert.Equal(t, []string{"api-gateway"}, filteredNames(RepositoryFilter{Include: include, Exclude: exclude}))
+	assert.Equal(t, []string{"gh-actions-usage", "terraform-aws"}, filteredNames(RepositoryFilter{Include: regexp}))
+}
+
+func TestRepositoryFilter_Validate(t *testing.T) {
+	require.NoError(t, RepositoryFilter{Visibility: "internal"}.Validate())
+
+	err := RepositoryFilter{Visibility: "secret"}.Validate()
+
+	require.ErrorIs(t, err, InvalidVisibilityError("secret"))
+	assert.Equal(t, "Invalid visibility: secret (must be public, private or internal)", err.Error())
+}
+
+func TestParseNamePattern(t *testing.T) {
+	for _, pattern := range []string{"api-*", "codiform/*-legacy", "/^(gh|terraform)-/"} {
+		parsed, err := ParseNamePattern(pattern)
+		require.NoError(t, err)
+		assert.Equal(t, pattern, parsed.String())
+	}
+}
+
+func TestParseNamePattern_Invalid(t *testing.T) {
+	_, err := ParseNamePattern("/(unclosed/")
+	require.Error(t, err)
+
+	_, err = ParseNamePattern("[unclosed")
+	require.Error(t, err)
+}
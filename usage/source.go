@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// UsageSource is where a Collector gets repositories, workflows and their usage from. *client.Client gets
+// them from the GitHub REST API; fake.Source keeps them in memory, for tests and demos.
+type UsageSource interface {
+	// GetCurrentRepository returns the repository in the current directory, or nil if there is none
+	GetCurrentRepository(ctx context.Context) (*client.Repository, error)
+	// GetRepository returns the repository with the full name (e.g. codiform/gh-actions-usage), or nil if
+	// there is none
+	GetRepository(ctx context.Context, fullName string) (*client.Repository, error)
+	// GetUser returns the user or organization with the login, or nil if there is none
+	GetUser(ctx context.Context, name string) (*client.User, error)
+	GetAllRepositories(ctx context.Context, user *client.User) ([]*client.Repository, error)
+	GetWorkflows(ctx context.Context, repository client.Repository) ([]client.Workflow, error)
+	GetWorkflowUsage(ctx context.Context, repository client.Repository, workflow client.Workflow) (*client.Usage, error)
+}
+
+// RunSource is a UsageSource that can also get the runs of a workflow, which Options.Runs and Options.Jobs
+// require
+type RunSource interface {
+	UsageSource
+	GetWorkflowRuns(ctx context.Context, repository client.Repository, workflow client.Workflow, since time.Time) ([]client.Run, error)
+	GetRunUsage(ctx context.Context, repository client.Repository, run client.Run) (*client.RunUsage, error)
+	GetRunJobs(ctx context.Context, repository client.Repository, run client.Run) ([]client.Job, error)
+}
+
+// BillingSource is a UsageSource that can also get the billing summary of an owner, which Options.Billing
+// requires
+type BillingSource interface {
+	UsageSource
+	// GetBilling returns the billing summary of the user or organization, or nil if it isn't available
+	GetBilling(ctx context.Context, user *client.User) (*client.Billing, error)
+}
+
+// The REST client can provide everything the options need
+var (
+	_ RunSource     = (*client.Client)(nil)
+	_ BillingSource = (*client.Client)(nil)
+)
+
+// UnsupportedOptionError is an error when the options need something that the UsageSource can't provide
+type UnsupportedOptionError string
+
+// Error returns a formatted error message for UnsupportedOptionError
+func (e UnsupportedOptionError) Error() string {
+	return fmt.Sprintf("The usage source can't collect %s", string(e))
+}
+
+// Supports checks that the source can provide everything the options need
+func (o Options) Supports(source UsageSource) error {
+	if _, ok := source.(RunSource); (o.Runs || o.Jobs) && !ok {
+		return UnsupportedOptionError("runs")
+	}
+	if _, ok := source.(BillingSource); o.Billing && !ok {
+		return UnsupportedOptionError("billing")
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+// Package usage collects the GitHub Actions usage of users, organizations and repositories, for the
+// gh-actions-usage command or any other tool that embeds it.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+)
+
+// DefaultConcurrency is the number of API requests made at a time when the options don't say
+const DefaultConcurrency = 4
+
+// Options decide what a Collector collects, and how quickly
+type Options struct {
+	// Targets are the users, organizations and repositories (e.g. codiform/gh-actions-usage) to collect the
+	// usage of; if there are none, the repository in the current directory is used
+	Targets []string
+	// Filter decides which of the repositories of user and organization targets are collected
+	Filter RepositoryFilter
+	// Concurrency is the most API requests made at a time; zero is DefaultConcurrency
+	Concurrency int
+	// Runs collects the usage of each workflow run in the billing period, which takes a request per run
+	Runs bool
+	// Jobs collects the jobs of each run as well, which takes another request per run; it implies Runs, since the
+	// jobs are found through the runs
+	Jobs bool
+	// Billing collects the billing summary of the user and organization targets
+	Billing bool
+	// AsOf picks the billing period that runs are collected for, e.g. when a recording was made; zero is now
+	AsOf time.Time
+	// Progress is called as collection progresses, if set; it's called by one request at a time, and should
+	// return quickly
+	Progress func(progress Progress)
+}
+
+// Progress is how far collection has got, reported each time the workflows of a repository are listed and
+// each time the usage of a workflow is collected; repositories and workflows that fail count as done
+type Progress struct {
+	// Repositories is how many repositories are being collected, and Listed how many of them have had their
+	// workflows listed
+	Repositories int
+	Listed       int
+	// Workflows is how many workflows have been listed so far, and Collected how many of them have had their
+	// usage collected (but not necessarily their runs)
+	Workflows int
+	Collected int
+}
+
+// InvalidConcurrencyError is an error when the requested concurrency can't be used
+type InvalidConcurrencyError int
+
+// Error returns a formatted error message for InvalidConcurrencyError
+func (e InvalidConcurrencyError) Error() string {
+	return fmt.Sprintf("Invalid concurrency: %d (must not be negative; 0 uses the default)", int(e))
+}
+
+// Validate checks that the options can be used before any requests are made
+func (o Options) Validate() error {
+	if o.Concurrency < 0 {
+		return InvalidConcurrencyError(o.Concurrency)
+	}
+	return o.Filter.Validate()
+}
+
+// UnknownRepoError is an error condition when a repository cannot be found
+type UnknownRepoError string
+
+// Error returns a formatted error message for UnknownRepoError
+func (e UnknownRepoError) Error() string {
+	return "Unknown repository: " + string(e)
+}
+
+// UnknownUserError is an error condition when a user cannot be found
+type UnknownUserError string
+
+// Error returns a formatted error message for UnknownUserError
+func (e UnknownUserError) Error() string {
+	return "Unknown user: " + string(e)
+}
+
+// NoCurrentRepositoryError is an error when there are no targets, and no repository in the current directory
+type NoCurrentRepositoryError struct{}
+
+// Error returns a formatted error message for NoCurrentRepositoryError
+func (e NoCurrentRepositoryError) Error() string {
+	return "No current repository found."
+}
+
+// Targets are the repositories to collect the usage of, found from the targets in the options
+type Targets struct {
+	Repositories []*client.Repository
+	// Owners are the users and organizations that were targeted as a whole, for their billing summaries
+	Owners []*client.User
+}
+
+// Report is the usage collected for the targets, along with anything that couldn't be collected
+type Report struct {
+	Usage client.RepoUsage
+	// Owners are the users and organizations that were targeted as a whole
+	Owners []*client.User
+	// Failures are the repositories, workflows and owners whose usage couldn't be collected
+	Failures []client.UsageError
+	// Billing is the billing summary for owners, by login, when it was requested and available
+	Billing map[string]*client.Billing
+}
+
+// Collector collects usage from a UsageSource, usually the GitHub API
+type Collector struct {
+	source  UsageSource
+	options Options
+}
+
+// NewCollector returns a Collector that gets usage from the source, e.g. a *client.Client
+func NewCollector(source UsageSource, options Options) *Collector {
+	if options.Concurrency == 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+	options.Runs = options.Runs || options.Jobs
+	return &Collector{source: source, options: options}
+}
+
+// Collect finds the repositories for the targets, then collects their usage. Repositories, workflows and
+// owners that fail are in the report's failures alongside the usage that was collected; an error is only
+// returned if collection had to stop, if the options aren't valid, or if the source can't provide what the
+// options need (see Options.Supports). If ctx is cancelled (e.g. by an interrupt or a timeout), requests in
+// flight are cancelled and the usage collected so far is returned along with the cause.
+func (c *Collector) Collect(ctx context.Context) (*Report, error) {
+	targets, err := c.Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.CollectTargets(ctx, targets)
+}
+
+// Resolve finds the repositories for each target, including the repositories of users and organizations
+// that match the filter, or the repository in the current directory if there are no targets
+func (c *Collector) Resolve(ctx context.Context) (Targets, error) {
+	if err := c.options.Validate(); err != nil {
+		return Targets{}, err
+	}
+	if len(c.options.Targets) == 0 {
+		repo, err := c.source.GetCurrentRepository(ctx)
+		if err != nil {
+			return Targets{}, err //nolint:wrapcheck // the client's error already describes the current repository
+		}
+		if repo == nil {
+			return Targets{}, NoCurrentRepositoryError{}
+		}
+		return Targets{Repositories: []*client.Repository{repo}}, nil
+	}
+
+	var targets Targets
+	for _, target := range c.options.Targets {
+		if strings.ContainsRune(target, '/') {
+			repo, err := c.getRepository(ctx, target)
+			if err != nil {
+				return Targets{}, err
+			}
+			targets.Repositories = append(targets.Repositories, repo)
+		} else {
+			owner, repos, err := c.getOwner(ctx, target)
+			if err != nil {
+				return Targets{}, err
+			}
+			targets.Owners = append(targets.Owners, owner)
+			targets.Repositories = append(targets.Repositories, repos...)
+		}
+	}
+	return targets, nil
+}
+
+func (c *Collector) getRepository(ctx context.Context, repoName string) (*client.Repository, error) {
+	repo, err := c.source.GetRepository(ctx, repoName)
+	if err != nil {
+		return nil, fmt.Errorf("could not get repository: %w", err)
+	}
+	if repo == nil {
+		return nil, UnknownRepoError(repoName)
+	}
+	return repo, nil
+}
+
+func (c *Collector) getOwner(ctx context.Context, userName string) (*client.User, []*client.Repository, error) {
+	user, err := c.source.GetUser(ctx, userName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get user: %w", err)
+	}
+	if user == nil {
+		return nil, nil, UnknownUserError(userName)
+	}
+
+	repos, err := c.source.GetAllRepositories(ctx, user)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get repositories: %w", err)
+	}
+	return user, c.options.Filter.apply(repos), nil
+}
+
+// CollectTargets collects the usage of targets that were already resolved, the same way as Collect
+func (c *Collector) CollectTargets(ctx context.Context, targets Targets) (*Report, error) {
+	if err := c.options.Validate(); err != nil {
+		return nil, err
+	}
+	if err := c.options.Supports(c.source); err != nil {
+		return nil, err
+	}
+	usage, failures, err := collectUsage(ctx, c.source, targets.Repositories, c.options)
+	if usage == nil {
+		return nil, err
+	}
+	report := &Report{Usage: usage, Owners: targets.Owners, Failures: failures}
+	if c.options.Billing && ctx.Err() == nil {
+		var billingFailures []client.UsageError
+		report.Billing, billingFailures = collectBilling(ctx, c.source.(BillingSource), targets.Owners)
+		report.Failures = append(report.Failures, billingFailures...)
+	}
+	return report, err
+}
@@ -0,0 +1,99 @@
+package usage
+
+import (
+	"testing"
+
+	"github.com/geoffreywiseman/gh-actions-usage/client"
+	"github.com/geoffreywiseman/gh-actions-usage/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	// Given
+	source := fake.New()
+	source.AddUser("codiform", "Organization")
+	repo := source.AddRepository("codiform/gh-actions-usage", client.Repository{})
+	source.AddRepository("codiform/legacy", client.Repository{Archived: true})
+	source.AddRepository("geoffreywiseman/gh-actuse", client.Repository{})
+	source.AddWorkflow(repo, client.Workflow{Name: "CI", Path: ".github/workflows/ci.yml"}, map[string]uint{"UBUNTU": 500})
+	source.SetBilling("codiform", client.Billing{TotalMinutesUsed: 305, IncludedMinutes: 3000})
+	var progress []Progress
+	collector := NewCollector(source, Options{
+		Targets:  []string{"codiform", "geoffreywiseman/gh-actuse"},
+		Filter:   RepositoryFilter{ExcludeArchived: true},
+		Billing:  true,
+		Progress: func(p Progress) { progress = append(progress, p) },
+	})
+
+	// When
+	report, err := collector.Collect(t.Context())
+
+	// Then
+	require.NoError(t, err)
+	assert.Empty(t, report.Failures)
+	require.Len(t, report.Usage, 2)
+	usage := make(map[string]uint)
+	for repo, workflows := range report.Usage {
+		usage[repo.FullName] = 0
+		for _, u := range workflows {
+			usage[repo.FullName] += u.TotalMs()
+		}
+	}
+	assert.Equal(t, map[string]uint{"codiform/gh-actions-usage": 500, "geoffreywiseman/gh-actuse": 0}, usage)
+	require.Len(t, report.Owners, 1)
+	assert.Equal(t, "codiform", report.Owners[0].Login)
+	assert.Equal(t, uint(305), report.Billing["codiform"].TotalMinutesUsed)
+	require.NotEmpty(t, progress)
+	assert.Equal(t, Progress{Repositories: 2, Listed: 2, Workflows: 1, Collected: 1}, progress[len(progress)-1])
+}
+
+func TestCollector_Resolve_Unknown(t *testing.T) {
+	// Given
+	source := fake.New()
+
+	// When
+	_, repoErr := NewCollector(source, Options{Targets: []string{"codiform/missing"}}).Resolve(t.Context())
+	_, userErr := NewCollector(source, Options{Targets: []string{"nobody"}}).Resolve(t.Context())
+
+	// Then
+	require.ErrorIs(t, repoErr, UnknownRepoError("codiform/missing"))
+	require.ErrorIs(t, userErr, UnknownUserError("nobody"))
+}
+
+func TestCollector_Invalid(t *testing.T) {
+	// Given
+	collector := NewCollector(fake.New(), Options{Targets: []string{"codiform"}, Concurrency: -1})
+
+	// When
+	_, resolveErr := collector.Resolve(t.Context())
+	_, collectErr := collector.CollectTargets(t.Context(), Targets{})
+
+	// Then
+	require.ErrorIs(t, resolveErr, InvalidConcurrencyError(-1))
+	require.ErrorIs(t, collectErr, InvalidConcurrencyError(-1))
+}
+
+func TestOptions_Validate(t *testing.T) {
+	require.NoError(t, Options{}.Validate())
+	require.ErrorIs(t, Options{Concurrency: -1}.Validate(), InvalidConcurrencyError(-1))
+	require.ErrorIs(t, Options{Filter: RepositoryFilter{Visibility: "secret"}}.Validate(), InvalidVisibilityError("secret"))
+}
+
+// usageOnly is a UsageSource that can't get runs or billing
+type usageOnly struct {
+	UsageSource
+}
+
+func TestOptions_Supports(t *testing.T) {
+	complete := fake.New()
+	source := usageOnly{complete}
+
+	require.NoError(t, Options{Runs: true, Billing: true}.Supports(complete))
+	require.NoError(t, Options{}.Supports(source))
+	require.ErrorIs(t, Options{Jobs: true}.Supports(source), UnsupportedOptionError("runs"))
+	require.ErrorIs(t, Options{Billing: true}.Supports(source), UnsupportedOptionError("billing"))
+
+	_, err := NewCollector(source, Options{Runs: true}).CollectTargets(t.Context(), Targets{})
+	require.ErrorIs(t, err, UnsupportedOptionError("runs"))
+}